@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/api"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+	"github.com/oapi-codegen/runtime/types"
+)
+
+// Trams which are still running after the end of the schedule are given
+// this much extra time to finish their trips before the run is cut off.
+const MAX_OVERTIME = 2 * 60 * 60 // 2 hours
+
+type options struct {
+	serverURL          string
+	cityID             string
+	cityFile           string
+	weekday            string
+	date               string
+	customScheduleFile string
+	passengerModelFile string
+	odMatrixFile       string
+	demandModelFile    string
+	gravityModelFile   string
+	choiceModelFile    string
+	replanningFile     string
+	output             string
+	tramWorkerCount    uint
+	seed               int64
+	resumeFile         string
+	snapshotFile       string
+	snapshotTime       string
+	disruptionsFile    string
+	vehicleTypesFile   string
+	blocksFile         string
+	depotsFile         string
+	dwellTimeFile      string
+	failureModelFile   string
+	holdingFile        string
+}
+
+func parseOptions() (opts options) {
+	flag.StringVar(&opts.serverURL, "server", api.ServerURL, "server URL base")
+	flag.StringVar(&opts.cityID, "city", "", "ID of the city to simulate")
+	flag.StringVar(&opts.cityFile, "city-file", "", "path to city data JSON or exported simulation ZIP file used instead of the server")
+	flag.StringVar(&opts.weekday, "weekday", "", "weekday of the schedule, e.g. monday")
+	flag.StringVar(&opts.date, "date", "", "date of the schedule in YYYY-MM-DD format")
+	flag.StringVar(&opts.customScheduleFile, "schedule", "", "path to custom GTFS schedule ZIP file")
+	flag.StringVar(&opts.passengerModelFile, "passengers", "", "path to passenger model CSV file")
+	flag.StringVar(&opts.odMatrixFile, "od-matrix", "", "path to CSV file with origin-destination matrix of passenger demand")
+	flag.StringVar(&opts.demandModelFile, "demand", "", "path to JSON file with parameters of random passenger demand")
+	flag.StringVar(&opts.gravityModelFile, "gravity", "", "path to JSON file with parameters of gravity model of passenger demand")
+	flag.StringVar(&opts.choiceModelFile, "choice", "", "path to JSON file with weights of the choice model between Pareto-optimal journeys")
+	flag.StringVar(&opts.replanningFile, "replanning", "", "path to JSON file with parameters of re-planning travel of passengers waiting for late or stopped trams")
+	flag.StringVar(&opts.output, "o", "", "path to the output ZIP file (default <city>-<weekday|date>.zip)")
+	flag.Int64Var(&opts.seed, "seed", -1, "seed of random number generators (negative for random seed)")
+	flag.StringVar(&opts.resumeFile, "resume", "", "path to snapshot file to resume the simulation from")
+	flag.StringVar(&opts.snapshotFile, "snapshot", "", "path to snapshot file saved at -snapshot-time")
+	flag.StringVar(&opts.snapshotTime, "snapshot-time", "", "time of saving the snapshot in HH:MM:SS format")
+	flag.StringVar(&opts.vehicleTypesFile, "vehicles", "", "path to JSON file with vehicle types assigned to routes and trips")
+	flag.StringVar(&opts.blocksFile, "blocks", "", "path to CSV file with trips assigned to vehicle blocks (default inferred from the schedule)")
+	flag.StringVar(&opts.depotsFile, "depots", "", "path to JSON file with a list of depots")
+	flag.StringVar(&opts.dwellTimeFile, "dwell", "", "path to JSON file with parameters of the dwell time model")
+	flag.StringVar(&opts.failureModelFile, "failures", "", "path to JSON file with parameters of the tram failure model")
+	flag.StringVar(&opts.holdingFile, "holding", "", "path to JSON file with holding control strategies of routes")
+	flag.StringVar(&opts.disruptionsFile, "disruptions", "", "path to JSON file with a list of disruptions, replacing ones restored with -resume")
+	flag.UintVar(&opts.tramWorkerCount, "workers", 0, "number of tram workers (0 for CPU count based default)")
+	flag.Parse()
+
+	return
+}
+
+func (o *options) getParameters() (parameters simulation.SimulationParameters, err error) {
+	parameters.CityID = o.cityID
+
+	if o.seed >= 0 {
+		seed := uint64(o.seed)
+		parameters.Seed = &seed
+	}
+
+	if o.weekday != "" {
+		weekday := api.Weekday(strings.ToLower(o.weekday))
+		parameters.Weekday = &weekday
+	}
+
+	if o.date != "" {
+		var date types.Date
+		if err = date.UnmarshalText([]byte(o.date)); err != nil {
+			return parameters, fmt.Errorf("invalid date %q: %w", o.date, err)
+		}
+		parameters.Date = &date
+	}
+
+	if o.customScheduleFile != "" {
+		if parameters.CustomSchedule, err = os.ReadFile(o.customScheduleFile); err != nil {
+			return
+		}
+	}
+
+	if o.passengerModelFile != "" {
+		if parameters.PassengerModel, err = os.ReadFile(o.passengerModelFile); err != nil {
+			return
+		}
+	}
+
+	if o.odMatrixFile != "" {
+		if parameters.ODMatrix, err = os.ReadFile(o.odMatrixFile); err != nil {
+			return
+		}
+	}
+
+	if o.vehicleTypesFile != "" {
+		if parameters.VehicleTypes, err = os.ReadFile(o.vehicleTypesFile); err != nil {
+			return
+		}
+	}
+
+	if o.blocksFile != "" {
+		if parameters.Blocks, err = os.ReadFile(o.blocksFile); err != nil {
+			return
+		}
+	}
+
+	if o.depotsFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.depotsFile); err != nil {
+			return
+		}
+
+		if err = json.Unmarshal(data, &parameters.Depots); err != nil {
+			return parameters, fmt.Errorf("error reading depots: %w", err)
+		}
+	}
+
+	if o.dwellTimeFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.dwellTimeFile); err != nil {
+			return
+		}
+
+		parameters.DwellTimeModel = &tram.LinearDwellTimeModel{}
+		if err = json.Unmarshal(data, parameters.DwellTimeModel); err != nil {
+			return parameters, fmt.Errorf("error reading dwell time model: %w", err)
+		}
+	}
+
+	if o.failureModelFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.failureModelFile); err != nil {
+			return
+		}
+
+		parameters.FailureModel = &tram.FailureModel{}
+		if err = json.Unmarshal(data, parameters.FailureModel); err != nil {
+			return parameters, fmt.Errorf("error reading failure model: %w", err)
+		}
+	}
+
+	if o.demandModelFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.demandModelFile); err != nil {
+			return
+		}
+
+		parameters.DemandModel = &passenger.RandomDemandModel{}
+		if err = json.Unmarshal(data, parameters.DemandModel); err != nil {
+			return parameters, fmt.Errorf("error reading demand model: %w", err)
+		}
+	}
+
+	if o.gravityModelFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.gravityModelFile); err != nil {
+			return
+		}
+
+		parameters.GravityModel = &passenger.GravityDemandModel{}
+		if err = json.Unmarshal(data, parameters.GravityModel); err != nil {
+			return parameters, fmt.Errorf("error reading gravity model: %w", err)
+		}
+	}
+
+	if o.choiceModelFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.choiceModelFile); err != nil {
+			return
+		}
+
+		parameters.ChoiceModel = &travelplan.ChoiceModel{}
+		if err = json.Unmarshal(data, parameters.ChoiceModel); err != nil {
+			return parameters, fmt.Errorf("error reading choice model: %w", err)
+		}
+	}
+
+	if o.replanningFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.replanningFile); err != nil {
+			return
+		}
+
+		// Parameters missing in the file keep their default values
+		replanningModel := passenger.DEFAULT_REPLANNING_MODEL
+		if err = json.Unmarshal(data, &replanningModel); err != nil {
+			return parameters, fmt.Errorf("error reading replanning model: %w", err)
+		}
+		parameters.Replanning = &replanningModel
+	}
+
+	return
+}
+
+func (o *options) getOutputFilename() string {
+	if o.output != "" {
+		return o.output
+	}
+
+	suffix := o.weekday
+	if o.date != "" {
+		suffix = o.date
+	}
+
+	if suffix == "" {
+		return fmt.Sprintf("%s.zip", o.cityID)
+	}
+
+	return fmt.Sprintf("%s-%s.zip", o.cityID, suffix)
+}
+
+func parseTime(value string) (uint, error) {
+	t, err := time.Parse("15:04:05", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM:SS)", value)
+	}
+
+	return uint(t.Hour()*3600 + t.Minute()*60 + t.Second()), nil
+}
+
+func saveSnapshot(sim *simulation.Simulation, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return sim.SaveSnapshotToWriter(file)
+}
+
+func loadSnapshot(sim *simulation.Simulation, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return sim.LoadSnapshotFromReader(file)
+}
+
+// Replaces disruptions of the simulation, including ones restored from a snapshot
+func setDisruptions(sim *simulation.Simulation, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var disruptions []controlcenter.Disruption
+	if err := json.NewDecoder(file).Decode(&disruptions); err != nil {
+		return fmt.Errorf("error reading disruptions: %w", err)
+	}
+
+	for _, disruption := range sim.GetDisruptions() {
+		if message := sim.RemoveDisruption(disruption.ID); message != "" {
+			return fmt.Errorf("error removing disruption: %s", message)
+		}
+	}
+
+	for _, disruption := range disruptions {
+		if message := sim.AddDisruption(disruption); message != "" {
+			return fmt.Errorf("error adding disruption: %s", message)
+		}
+	}
+
+	return nil
+}
+
+func setHoldingControls(sim *simulation.Simulation, filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var controls []controlcenter.HoldingControl
+	if err := json.Unmarshal(data, &controls); err != nil {
+		return fmt.Errorf("error reading holding controls: %w", err)
+	}
+
+	if message := sim.SetHoldingControls(controls); message != "" {
+		return fmt.Errorf("error setting holding controls: %s", message)
+	}
+
+	return nil
+}
+
+func run(opts options) error {
+	if opts.cityID == "" {
+		return fmt.Errorf("city ID is required")
+	}
+
+	// Schedule of a city file is already chosen when the file is created
+	if opts.cityFile != "" && (opts.weekday != "" || opts.date != "" || opts.customScheduleFile != "") {
+		return fmt.Errorf("-weekday, -date and -schedule can't be given together with -city-file")
+	}
+
+	parameters, err := opts.getParameters()
+	if err != nil {
+		return err
+	}
+
+	var snapshotTime uint
+	if opts.snapshotFile != "" {
+		if snapshotTime, err = parseTime(opts.snapshotTime); err != nil {
+			return err
+		}
+	}
+
+	api.ServerURL = opts.serverURL
+	apiClient := api.NewAPIClient()
+
+	currentCity := city.City{}
+	sim := simulation.NewSimulation(&apiClient, &currentCity)
+
+	if opts.cityFile == "" {
+		if message := sim.InitializeCity(parameters); message != "" {
+			return fmt.Errorf("error initializing city: %s", message)
+		}
+	} else {
+		if err := currentCity.LoadFromFile(opts.cityID, opts.cityFile); err != nil {
+			return err
+		}
+
+		if err := sim.InitializeFleet(parameters); err != nil {
+			return err
+		}
+
+		if err := sim.InitializePassengers(parameters); err != nil {
+			return err
+		}
+	}
+
+	if message := sim.InitializeSimulation(opts.tramWorkerCount); message != "" {
+		return fmt.Errorf("error initializing simulation: %s", message)
+	}
+
+	// There is no playback of the simulation, so the timeline isn't recorded
+	sim.SetTimelineRecording(false)
+
+	timeBounds := sim.GetTimeBounds()
+	startTime := timeBounds.StartTime
+
+	if opts.resumeFile != "" {
+		if err := loadSnapshot(&sim, opts.resumeFile); err != nil {
+			return err
+		}
+
+		startTime = sim.GetTime() + 1
+	}
+
+	if opts.snapshotFile != "" && (snapshotTime < startTime || snapshotTime > timeBounds.EndTime) {
+		return fmt.Errorf("snapshot time %d is outside of the simulated time from %d to %d", snapshotTime, startTime, timeBounds.EndTime)
+	}
+
+	if opts.holdingFile != "" {
+		if err := setHoldingControls(&sim, opts.holdingFile); err != nil {
+			return err
+		}
+	}
+
+	if opts.disruptionsFile != "" {
+		if err := setDisruptions(&sim, opts.disruptionsFile); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Simulating %s from %d to %d with seed %d", opts.cityID, startTime, timeBounds.EndTime, sim.GetSeed())
+
+	currentTime := startTime
+	for ; currentTime <= timeBounds.EndTime || !sim.AreTramsFinished(); currentTime++ {
+		if currentTime > timeBounds.EndTime+MAX_OVERTIME {
+			log.Printf("Not all trams finished their trips by %d", currentTime)
+			break
+		}
+
+		sim.AdvanceTrams(currentTime)
+
+		if opts.snapshotFile != "" && currentTime == snapshotTime {
+			if err := saveSnapshot(&sim, opts.snapshotFile); err != nil {
+				return err
+			}
+
+			log.Printf("Snapshot at %d written to %s", currentTime, opts.snapshotFile)
+		}
+	}
+
+	filename := opts.getOutputFilename()
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := sim.ExportToWriter(file); err != nil {
+		return err
+	}
+
+	log.Printf("Simulation finished at %d, results written to %s", currentTime, filename)
+
+	return nil
+}
+
+func main() {
+	if err := run(parseOptions()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,6 +2,7 @@ package city
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"slices"
 	"sort"
@@ -20,9 +21,11 @@ type City struct {
 	nodesByID        map[uint64]graph.GraphNode
 	stopsByID        map[uint64]*graph.GraphTramStop
 	stopsByName      map[string]map[uint64]*graph.GraphTramStop
+	stopIDsByName    map[string][]uint64
 	tripsByID        map[uint]*trip.TramTrip
 	routesByStopID   map[uint64][]RouteInfo
 	plannedArrivals  map[uint64][]PlannedArrival
+	timetable        *Timetable
 	bounds           LatLonBounds
 	responseCityData *api.ResponseCityData
 }
@@ -63,6 +66,10 @@ func (c *City) FetchCity(
 		return err
 	}
 
+	return c.LoadCityData(cityID, responseCityData)
+}
+
+func (c *City) LoadCityData(cityID string, responseCityData *api.ResponseCityData) error {
 	c.CityID = cityID
 	c.responseCityData = responseCityData
 
@@ -91,6 +98,11 @@ func (c *City) FetchCity(
 		c.stopsByName[name][stopID] = stop
 	}
 
+	c.stopIDsByName = make(map[string][]uint64, len(c.stopsByName))
+	for name, stops := range c.stopsByName {
+		c.stopIDsByName[name] = slices.Sorted(maps.Keys(stops))
+	}
+
 	c.tripsByID = make(map[uint]*trip.TramTrip)
 	for i, route := range c.tramRoutes {
 		for j, trip := range route.Trips {
@@ -100,6 +112,7 @@ func (c *City) FetchCity(
 
 	c.CityID = cityID
 	c.routesByStopID = c.GetRoutesByStopID()
+	c.timetable = newTimetable(c)
 	c.Reset()
 
 	c.bounds = GetBoundsFromNodes(c.nodesByID)
@@ -159,6 +172,15 @@ func (c *City) GetStopsInGroup(stopID uint64) map[uint64]*graph.GraphTramStop {
 	return c.stopsByName[groupName]
 }
 
+// Returns IDs of stops in the same group as the given stop, sorted in ascending order
+func (c *City) GetStopIDsInGroup(stopID uint64) []uint64 {
+	if _, ok := c.stopsByID[stopID]; !ok {
+		panic(fmt.Sprintf("Stop with ID %d not found", stopID))
+	}
+
+	return c.stopIDsByName[c.stopsByID[stopID].GetGroupName()]
+}
+
 func (c *City) GetTramRoutes() []trip.TramRoute {
 	return c.tramRoutes
 }
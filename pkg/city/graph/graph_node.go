@@ -8,6 +8,7 @@ import (
 
 type GraphNode interface {
 	NodeBlocker
+	NodeCloser
 	IsTramStop() bool
 	GetID() uint64
 	GetCoordinates() (float32, float32)
@@ -4,6 +4,7 @@ import "github.com/TNSEngineerEdition/WailsClient/pkg/api"
 
 type GraphTrackNode struct {
 	NodeBlock
+	NodeClosure
 	Details api.ResponseGraphNode `json:"details"`
 }
 
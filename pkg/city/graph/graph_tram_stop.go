@@ -6,6 +6,7 @@ import (
 
 type GraphTramStop struct {
 	NodeBlock
+	NodeClosure
 	Details api.ResponseGraphTramStop `json:"details"`
 }
 
@@ -5,18 +5,27 @@ import (
 )
 
 type NodeBlocker interface {
-	TryBlocking(tramID uint) bool
+	Claim(tramID, time uint) bool
+	TryBlocking(tramID, time uint) bool
 	Unblock(tramID uint)
 	ForceUnblock()
+	ForceBlocking(tramID uint)
+	GetBlockingTramID() uint
 }
 
 type NodeBlock struct {
 	isBlocked      bool
 	blockingTramID uint
+	claimingTramID uint
+	claimTime      uint
 	mu             sync.Mutex
 }
 
-func (g *NodeBlock) TryBlocking(tramID uint) bool {
+// Registers the intent of blocking the node at the given time. When multiple
+// trams claim the same free node, only the tram with the lowest ID is allowed
+// to block it, so the result doesn't depend on the order of advancing trams.
+// Returns false if the node is already blocked by another tram.
+func (g *NodeBlock) Claim(tramID, time uint) bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
@@ -24,6 +33,26 @@ func (g *NodeBlock) TryBlocking(tramID uint) bool {
 		return false
 	}
 
+	if g.claimTime != time || g.claimingTramID == 0 || tramID < g.claimingTramID {
+		g.claimingTramID = tramID
+		g.claimTime = time
+	}
+
+	return true
+}
+
+func (g *NodeBlock) TryBlocking(tramID, time uint) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.isBlocked {
+		return g.blockingTramID == tramID
+	}
+
+	if g.claimTime != time || g.claimingTramID != tramID {
+		return false
+	}
+
 	g.isBlocked = true
 	g.blockingTramID = tramID
 
@@ -46,4 +75,30 @@ func (g *NodeBlock) Unblock(tramID uint) {
 
 func (g *NodeBlock) ForceUnblock() {
 	g.unblock(true)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.claimingTramID = 0
+	g.claimTime = 0
+}
+
+func (g *NodeBlock) ForceBlocking(tramID uint) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.isBlocked = true
+	g.blockingTramID = tramID
+}
+
+// Returns ID of the tram blocking the node or 0 if the node is not blocked
+func (g *NodeBlock) GetBlockingTramID() uint {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.isBlocked {
+		return 0
+	}
+
+	return g.blockingTramID
 }
@@ -0,0 +1,50 @@
+package graph
+
+// Closures are changed only between advancing trams,
+// so reading them while advancing doesn't require locking.
+type NodeCloser interface {
+	Close()
+	Open()
+	CloseEdge(neighborID uint64)
+	OpenEdge(neighborID uint64)
+	IsClosed() bool
+	IsEdgeClosed(neighborID uint64) bool
+}
+
+// Counts closures of a node and of edges to its neighbors,
+// so that overlapping closures can be opened independently.
+type NodeClosure struct {
+	closureCount     int
+	edgeClosureCount map[uint64]int
+}
+
+func (n *NodeClosure) Close() {
+	n.closureCount++
+}
+
+func (n *NodeClosure) Open() {
+	n.closureCount = max(n.closureCount-1, 0)
+}
+
+func (n *NodeClosure) CloseEdge(neighborID uint64) {
+	if n.edgeClosureCount == nil {
+		n.edgeClosureCount = make(map[uint64]int)
+	}
+	n.edgeClosureCount[neighborID]++
+}
+
+func (n *NodeClosure) OpenEdge(neighborID uint64) {
+	if n.edgeClosureCount[neighborID] <= 1 {
+		delete(n.edgeClosureCount, neighborID)
+	} else {
+		n.edgeClosureCount[neighborID]--
+	}
+}
+
+func (n *NodeClosure) IsClosed() bool {
+	return n.closureCount > 0
+}
+
+func (n *NodeClosure) IsEdgeClosed(neighborID uint64) bool {
+	return n.edgeClosureCount[neighborID] > 0
+}
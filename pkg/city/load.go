@@ -0,0 +1,63 @@
+package city
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/api"
+)
+
+const CITY_DATA_FILENAME = "city_data.json"
+
+var zipFileSignature = []byte("PK\x03\x04")
+
+func (c *City) LoadFromFile(cityID, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.LoadFromReader(cityID, file)
+}
+
+// Loads city data either from city data JSON or from a ZIP file
+// created by simulation export, which includes city data JSON.
+func (c *City) LoadFromReader(cityID string, reader io.Reader) error {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(content, zipFileSignature) {
+		if content, err = readCityDataFromZip(content); err != nil {
+			return err
+		}
+	}
+
+	var responseCityData api.ResponseCityData
+	if err := json.Unmarshal(content, &responseCityData); err != nil {
+		return fmt.Errorf("error reading city data: %w", err)
+	}
+
+	return c.LoadCityData(cityID, &responseCityData)
+}
+
+func readCityDataFromZip(content []byte) ([]byte, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading ZIP file: %w", err)
+	}
+
+	cityDataFile, err := zipReader.Open(CITY_DATA_FILENAME)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s from ZIP file: %w", CITY_DATA_FILENAME, err)
+	}
+	defer cityDataFile.Close()
+
+	return io.ReadAll(cityDataFile)
+}
@@ -0,0 +1,145 @@
+package city
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
+)
+
+// Trips going through the same sequence of stops, sorted by time at each of the stops
+type RoutePattern struct {
+	Stops []int
+	Trips []*trip.TramTrip
+}
+
+// Position of a stop in a route pattern
+type PatternStop struct {
+	Pattern, Position int
+}
+
+// Timetable of the city precomputed for journey planning. Stops are referred to
+// by their indices in StopIDs.
+type Timetable struct {
+	StopIDs          []uint64
+	StopIndexByID    map[uint64]int
+	Patterns         []RoutePattern
+	PatternsByStop   [][]PatternStop
+	GroupStopsByStop [][]int
+	IsTransferStop   []bool
+}
+
+func newTimetable(c *City) *Timetable {
+	stopIDs := make([]uint64, 0, len(c.stopsByID))
+	for _, name := range slices.Sorted(maps.Keys(c.stopIDsByName)) {
+		stopIDs = append(stopIDs, c.stopIDsByName[name]...)
+	}
+
+	t := Timetable{
+		StopIDs:          stopIDs,
+		StopIndexByID:    make(map[uint64]int, len(stopIDs)),
+		PatternsByStop:   make([][]PatternStop, len(stopIDs)),
+		GroupStopsByStop: make([][]int, len(stopIDs)),
+		IsTransferStop:   make([]bool, len(stopIDs)),
+	}
+
+	for i, stopID := range stopIDs {
+		t.StopIndexByID[stopID] = i
+	}
+
+	for i, stopID := range stopIDs {
+		for _, groupStopID := range c.GetStopIDsInGroup(stopID) {
+			t.GroupStopsByStop[i] = append(t.GroupStopsByStop[i], t.StopIndexByID[groupStopID])
+		}
+		t.IsTransferStop[i] = c.IsTransferStop(stopID)
+	}
+
+	t.addRoutePatterns(c.tramRoutes)
+
+	return &t
+}
+
+// Groups trips by their sequences of stops. Trips overtaking others are put
+// into separate patterns, so that trips of a pattern keep their order at each stop.
+func (t *Timetable) addRoutePatterns(tramRoutes []trip.TramRoute) {
+	sequenceKeys := make([]string, 0)
+	tripsBySequenceKey := make(map[string][]*trip.TramTrip)
+
+	for i := range tramRoutes {
+		for j := range tramRoutes[i].Trips {
+			tramTrip := &tramRoutes[i].Trips[j]
+			if len(tramTrip.Stops) < 2 {
+				continue
+			}
+
+			key := getStopSequenceKey(tramTrip)
+			if _, ok := tripsBySequenceKey[key]; !ok {
+				sequenceKeys = append(sequenceKeys, key)
+			}
+			tripsBySequenceKey[key] = append(tripsBySequenceKey[key], tramTrip)
+		}
+	}
+
+	for _, key := range sequenceKeys {
+		trips := tripsBySequenceKey[key]
+		slices.SortStableFunc(trips, func(t1, t2 *trip.TramTrip) int {
+			return cmp.Or(cmp.Compare(t1.Stops[0].Time, t2.Stops[0].Time), cmp.Compare(t1.ID, t2.ID))
+		})
+
+		firstPattern := len(t.Patterns)
+		for _, tramTrip := range trips {
+			patternIndex := slices.IndexFunc(t.Patterns[firstPattern:], func(pattern RoutePattern) bool {
+				return !isOvertaking(tramTrip, pattern.Trips[len(pattern.Trips)-1])
+			})
+
+			if patternIndex == -1 {
+				t.addRoutePattern(tramTrip)
+			} else {
+				pattern := &t.Patterns[firstPattern+patternIndex]
+				pattern.Trips = append(pattern.Trips, tramTrip)
+			}
+		}
+	}
+}
+
+func (t *Timetable) addRoutePattern(tramTrip *trip.TramTrip) {
+	pattern := RoutePattern{
+		Stops: make([]int, len(tramTrip.Stops)),
+		Trips: []*trip.TramTrip{tramTrip},
+	}
+
+	for position, stop := range tramTrip.Stops {
+		pattern.Stops[position] = t.StopIndexByID[stop.ID]
+		t.PatternsByStop[pattern.Stops[position]] = append(
+			t.PatternsByStop[pattern.Stops[position]],
+			PatternStop{Pattern: len(t.Patterns), Position: position},
+		)
+	}
+
+	t.Patterns = append(t.Patterns, pattern)
+}
+
+func getStopSequenceKey(tramTrip *trip.TramTrip) string {
+	var builder strings.Builder
+	for _, stop := range tramTrip.Stops {
+		fmt.Fprintf(&builder, "%d,", stop.ID)
+	}
+	return builder.String()
+}
+
+// Checks if the trip is earlier than the other trip at any of the stops
+func isOvertaking(tramTrip, other *trip.TramTrip) bool {
+	for i, stop := range tramTrip.Stops {
+		if stop.Time < other.Stops[i].Time {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *City) GetTimetable() *Timetable {
+	return c.timetable
+}
@@ -21,25 +21,37 @@ type Coordinates struct {
 type RouteSegment struct {
 	StopIDs  []uint64      `json:"stopIDs"`
 	Polyline []Coordinates `json:"polyline"`
+	Detours  []RouteDetour `json:"detours,omitempty"`
 }
 
 type ControlCenter struct {
 	paths               map[stopPair]Path
 	segmentsByRouteName map[string][]RouteSegment
+	nodesByID           map[uint64]graph.GraphNode
+	disruptions         []*scheduledDisruption
+	disruptionRequests  *disruptionRequests
+	detours             *detourCache
+	holdingControls     map[string]*holdingControl
 }
 
-func NewControlCenter(city *city.City) ControlCenter {
+func NewControlCenter(city *city.City) (ControlCenter, error) {
+	nodesByID := city.GetNodesByID()
 	controlCenter := ControlCenter{
 		paths:               make(map[stopPair]Path),
 		segmentsByRouteName: make(map[string][]RouteSegment),
+		nodesByID:           nodesByID,
+		disruptionRequests:  &disruptionRequests{},
+		detours:             newDetourCache(),
+		holdingControls:     make(map[string]*holdingControl),
 	}
 
 	tramRoutes := city.GetTramRoutes()
-	nodesByID := city.GetNodesByID()
 
 	for _, route := range tramRoutes {
 		for _, trip := range route.Trips {
-			controlCenter.addPathsFromTrip(&trip, &nodesByID)
+			if err := controlCenter.addPathsFromTrip(&trip, &nodesByID); err != nil {
+				return ControlCenter{}, err
+			}
 		}
 	}
 
@@ -51,23 +63,32 @@ func NewControlCenter(city *city.City) ControlCenter {
 		controlCenter.setSegmentsByRouteName(&route)
 	}
 
-	return controlCenter
+	return controlCenter, nil
 }
 
 func (c *ControlCenter) addPathsFromTrip(
 	trip *trip.TramTrip,
 	nodesByID *map[uint64]graph.GraphNode,
-) {
+) error {
 	for i := 0; i < len(trip.Stops)-1; i++ {
 		stopPair := stopPair{
 			source:      trip.Stops[i].ID,
 			destination: trip.Stops[i+1].ID,
 		}
 
-		if _, ok := c.paths[stopPair]; !ok {
-			c.paths[stopPair] = getShortestPath(nodesByID, stopPair)
+		if _, ok := c.paths[stopPair]; ok {
+			continue
+		}
+
+		path, ok := getShortestPath(nodesByID, stopPair, nil)
+		if !ok {
+			return fmt.Errorf("no path found between %d and %d nodes of trip %d", stopPair.source, stopPair.destination, trip.ID)
 		}
+
+		c.paths[stopPair] = path
 	}
+
+	return nil
 }
 
 func getGraphNodes(route *trip.TramRoute) map[uint64]*structs.Set[uint64] {
@@ -127,12 +148,7 @@ func (c *ControlCenter) setSegmentsByRouteName(route *trip.TramRoute) {
 		var polyline []Coordinates
 
 		for i := 0; i < len(segment)-1; i++ {
-			path := c.GetPath(segment[i], segment[i+1])
-
-			for _, node := range path.Nodes {
-				lat, lon := node.GetCoordinates()
-				polyline = append(polyline, Coordinates{Lat: lat, Lon: lon})
-			}
+			polyline = append(polyline, c.GetPath(segment[i], segment[i+1]).getPolyline()...)
 		}
 
 		c.segmentsByRouteName[route.Name] = append(c.segmentsByRouteName[route.Name], RouteSegment{
@@ -150,9 +166,10 @@ func (c *ControlCenter) GetPath(sourceNodeID, destinationNodeID uint64) *Path {
 	panic(fmt.Sprintf("No path found between %d and %d nodes", sourceNodeID, destinationNodeID))
 }
 
+// Returns segments of the route together with detours around currently closed track
 func (c *ControlCenter) GetSegmentsForRoute(routeName string) []RouteSegment {
 	if segments, ok := c.segmentsByRouteName[routeName]; ok {
-		return segments
+		return c.withDetours(segments)
 	}
 
 	panic(fmt.Sprintf("Route %s not found", routeName))
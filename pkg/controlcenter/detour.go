@@ -0,0 +1,119 @@
+package controlcenter
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
+)
+
+// Detour of a route around closed track, leading from the source stop
+// to the destination stop and skipping stops which can't be reached.
+type RouteDetour struct {
+	SourceStopID      uint64        `json:"sourceStopID"`
+	DestinationStopID uint64        `json:"destinationStopID"`
+	SkippedStopIDs    []uint64      `json:"skippedStopIDs"`
+	Polyline          []Coordinates `json:"polyline"`
+}
+
+// Shortest paths avoiding closed track, valid until nodes or edges are opened or closed.
+// Missing detours are stored as nil values.
+type detourCache struct {
+	paths map[stopPair]*Path
+	mu    sync.Mutex
+}
+
+func newDetourCache() *detourCache {
+	return &detourCache{paths: make(map[stopPair]*Path)}
+}
+
+func (d *detourCache) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paths = make(map[stopPair]*Path)
+}
+
+func (c *ControlCenter) getDetourPath(sourceNodeID, destinationStopID uint64) *Path {
+	c.detours.mu.Lock()
+	defer c.detours.mu.Unlock()
+
+	key := stopPair{source: sourceNodeID, destination: destinationStopID}
+	if path, ok := c.detours.paths[key]; ok {
+		return path
+	}
+
+	var result *Path
+	if path, ok := getShortestPath(&c.nodesByID, key, isEdgeClosed); ok {
+		result = &path
+	}
+
+	c.detours.paths[key] = result
+	return result
+}
+
+// Finds the shortest path avoiding closed nodes and edges, which starts with the given
+// nodes and leads to the first reachable stop out of destinationStopIDs. Returns the path
+// and index of the reached stop, or false if none of the stops can be reached.
+func (c *ControlCenter) GetDetour(startNodes []graph.GraphNode, destinationStopIDs []uint64) (*Path, int, bool) {
+	sourceNode := startNodes[len(startNodes)-1]
+
+	for i, stopID := range destinationStopIDs {
+		detour := c.getDetourPath(sourceNode.GetID(), stopID)
+		if detour == nil {
+			continue
+		}
+
+		if len(startNodes) == 1 {
+			return detour, i, true
+		}
+
+		path := newPath(slices.Concat(startNodes[:len(startNodes)-1], detour.Nodes))
+		return &path, i, true
+	}
+
+	return nil, 0, false
+}
+
+func (c *ControlCenter) HasActiveClosures() bool {
+	return slices.ContainsFunc(c.disruptions, func(d *scheduledDisruption) bool {
+		return d.isActive
+	})
+}
+
+func (c *ControlCenter) getSegmentDetours(stopIDs []uint64) (detours []RouteDetour) {
+	for i := 0; i < len(stopIDs)-1; i++ {
+		if !c.GetPath(stopIDs[i], stopIDs[i+1]).IsClosedAfter(0) {
+			continue
+		}
+
+		path, index, ok := c.GetDetour([]graph.GraphNode{c.nodesByID[stopIDs[i]]}, stopIDs[i+1:])
+		if !ok {
+			continue
+		}
+
+		detours = append(detours, RouteDetour{
+			SourceStopID:      stopIDs[i],
+			DestinationStopID: stopIDs[i+1+index],
+			SkippedStopIDs:    slices.Clone(stopIDs[i+1 : i+1+index]),
+			Polyline:          path.getPolyline(),
+		})
+
+		i += index
+	}
+
+	return
+}
+
+func (c *ControlCenter) withDetours(segments []RouteSegment) []RouteSegment {
+	if !c.HasActiveClosures() {
+		return segments
+	}
+
+	result := slices.Clone(segments)
+	for i := range result {
+		result[i].Detours = c.getSegmentDetours(result[i].StopIDs)
+	}
+
+	return result
+}
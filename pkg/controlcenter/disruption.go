@@ -0,0 +1,256 @@
+package controlcenter
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Disruption closes a node or, if NeighborID is set, only the edge
+// from the node to its neighbor, between StartTime and EndTime.
+// Trams wait in front of the closure, unless HoldAtStop is set,
+// in which case trams wait at the last stop before the closure.
+type Disruption struct {
+	ID         uint    `json:"id"`
+	NodeID     uint64  `json:"nodeID"`
+	NeighborID *uint64 `json:"neighborID,omitempty"`
+	StartTime  uint    `json:"startTime"`
+	EndTime    uint    `json:"endTime"`
+	HoldAtStop bool    `json:"holdAtStop"`
+}
+
+type scheduledDisruption struct {
+	Disruption
+	isActive bool
+}
+
+// Additions and removals of disruptions requested while trams may be advancing.
+// They are applied by UpdateDisruptions, before advancing trams.
+type disruptionRequests struct {
+	mu               sync.Mutex
+	items            []disruptionRequest
+	lastDisruptionID uint
+}
+
+// Request of adding the disruption or, if it's nil, removing the disruption with removedID
+type disruptionRequest struct {
+	disruption *Disruption
+	removedID  uint
+}
+
+func (d *Disruption) isEdgeClosure() bool {
+	return d.NeighborID != nil
+}
+
+func (d *Disruption) isActiveAt(time uint) bool {
+	return d.StartTime <= time && time < d.EndTime
+}
+
+func (d *Disruption) isOnPath(path *Path) bool {
+	for i, node := range path.Nodes {
+		if node.GetID() != d.NodeID {
+			continue
+		}
+
+		if !d.isEdgeClosure() {
+			return true
+		}
+
+		if i < len(path.Nodes)-1 && path.Nodes[i+1].GetID() == *d.NeighborID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *ControlCenter) validateDisruption(disruption Disruption) error {
+	node, ok := c.nodesByID[disruption.NodeID]
+	if !ok {
+		return fmt.Errorf("node %d not found", disruption.NodeID)
+	}
+
+	if disruption.isEdgeClosure() {
+		if _, ok := node.GetNeighbors()[*disruption.NeighborID]; !ok {
+			return fmt.Errorf("node %d is not a neighbor of node %d", *disruption.NeighborID, disruption.NodeID)
+		}
+	}
+
+	if disruption.StartTime >= disruption.EndTime {
+		return fmt.Errorf("disruption has to start before it ends")
+	}
+
+	return nil
+}
+
+// Requests adding the disruption, returns its ID
+func (c *ControlCenter) AddDisruption(disruption Disruption) (uint, error) {
+	if err := c.validateDisruption(disruption); err != nil {
+		return 0, err
+	}
+
+	c.disruptionRequests.mu.Lock()
+	defer c.disruptionRequests.mu.Unlock()
+
+	c.disruptionRequests.lastDisruptionID++
+	disruption.ID = c.disruptionRequests.lastDisruptionID
+	c.disruptionRequests.items = append(c.disruptionRequests.items, disruptionRequest{disruption: &disruption})
+
+	return disruption.ID, nil
+}
+
+// Checks if the disruptions can be set with SetDisruptions
+func (c *ControlCenter) ValidateDisruptions(disruptions []Disruption) error {
+	for _, disruption := range disruptions {
+		if err := c.validateDisruption(disruption); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replaces all disruptions with the given ones, keeping their IDs.
+// Has to be called when trams aren't advancing.
+func (c *ControlCenter) SetDisruptions(disruptions []Disruption) error {
+	if err := c.ValidateDisruptions(disruptions); err != nil {
+		return err
+	}
+
+	c.disruptionRequests.mu.Lock()
+	defer c.disruptionRequests.mu.Unlock()
+
+	c.ResetDisruptions()
+	c.disruptions = make([]*scheduledDisruption, 0, len(disruptions))
+	c.disruptionRequests.items = nil
+	c.disruptionRequests.lastDisruptionID = 0
+
+	for _, disruption := range disruptions {
+		c.disruptions = append(c.disruptions, &scheduledDisruption{Disruption: disruption})
+		c.disruptionRequests.lastDisruptionID = max(c.disruptionRequests.lastDisruptionID, disruption.ID)
+	}
+
+	return nil
+}
+
+// Requests removing the disruption
+func (c *ControlCenter) RemoveDisruption(id uint) error {
+	c.disruptionRequests.mu.Lock()
+	defer c.disruptionRequests.mu.Unlock()
+
+	if !slices.ContainsFunc(c.getDisruptions(), func(d Disruption) bool { return d.ID == id }) {
+		return fmt.Errorf("disruption %d not found", id)
+	}
+
+	c.disruptionRequests.items = append(c.disruptionRequests.items, disruptionRequest{removedID: id})
+
+	return nil
+}
+
+// Returns disruptions including the requested changes
+func (c *ControlCenter) GetDisruptions() []Disruption {
+	c.disruptionRequests.mu.Lock()
+	defer c.disruptionRequests.mu.Unlock()
+
+	return c.getDisruptions()
+}
+
+// Requires the lock of disruption requests
+func (c *ControlCenter) getDisruptions() []Disruption {
+	result := make([]Disruption, 0, len(c.disruptions))
+	for _, d := range c.disruptions {
+		result = append(result, d.Disruption)
+	}
+
+	for _, request := range c.disruptionRequests.items {
+		if request.disruption != nil {
+			result = append(result, *request.disruption)
+		} else {
+			result = slices.DeleteFunc(result, func(d Disruption) bool { return d.ID == request.removedID })
+		}
+	}
+
+	return result
+}
+
+// Adds and removes disruptions as requested
+func (c *ControlCenter) applyDisruptionRequests() {
+	c.disruptionRequests.mu.Lock()
+	defer c.disruptionRequests.mu.Unlock()
+
+	for _, request := range c.disruptionRequests.items {
+		if request.disruption != nil {
+			c.disruptions = append(c.disruptions, &scheduledDisruption{Disruption: *request.disruption})
+			continue
+		}
+
+		index := slices.IndexFunc(c.disruptions, func(d *scheduledDisruption) bool {
+			return d.ID == request.removedID
+		})
+
+		c.setDisruptionActive(c.disruptions[index], false)
+		c.disruptions = slices.Delete(c.disruptions, index, index+1)
+	}
+
+	c.disruptionRequests.items = nil
+}
+
+func (c *ControlCenter) setDisruptionActive(d *scheduledDisruption, isActive bool) {
+	if d.isActive == isActive {
+		return
+	}
+
+	d.isActive = isActive
+	node := c.nodesByID[d.NodeID]
+	c.detours.clear()
+
+	switch {
+	case isActive && d.isEdgeClosure():
+		node.CloseEdge(*d.NeighborID)
+	case isActive:
+		node.Close()
+	case d.isEdgeClosure():
+		node.OpenEdge(*d.NeighborID)
+	default:
+		node.Open()
+	}
+}
+
+// Applies requested changes of disruptions, then opens and closes nodes and edges
+// according to disruptions active at the given time. Has to be called before advancing trams.
+func (c *ControlCenter) UpdateDisruptions(time uint) {
+	c.applyDisruptionRequests()
+
+	for _, d := range c.disruptions {
+		c.setDisruptionActive(d, d.isActiveAt(time))
+	}
+}
+
+// Opens all nodes and edges closed by disruptions
+func (c *ControlCenter) ResetDisruptions() {
+	for _, d := range c.disruptions {
+		c.setDisruptionActive(d, false)
+	}
+}
+
+// Returns true if a tram should wait at the source stop because
+// the path to the destination stop is closed by an active disruption.
+func (c *ControlCenter) IsHeldAtStop(sourceStopID, destinationStopID uint64) bool {
+	var path *Path
+
+	for _, d := range c.disruptions {
+		if !d.isActive || !d.HoldAtStop {
+			continue
+		}
+
+		if path == nil {
+			path = c.GetPath(sourceStopID, destinationStopID)
+		}
+
+		if d.isOnPath(path) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,34 @@
+package controlcenter
+
+import (
+	"fmt"
+	"io"
+)
+
+func (c *ControlCenter) DisruptionsToCSVBuffer(writer io.Writer) error {
+	writer.Write([]byte("disruption_id,node_id,neighbor_id,start_time,end_time,hold_at_stop\n"))
+
+	for _, d := range c.disruptions {
+		var neighborID string
+		if d.isEdgeClosure() {
+			neighborID = fmt.Sprint(*d.NeighborID)
+		}
+
+		_, err := fmt.Fprintf(
+			writer,
+			"%d,%d,%s,%d,%d,%t\n",
+			d.ID,
+			d.NodeID,
+			neighborID,
+			d.StartTime,
+			d.EndTime,
+			d.HoldAtStop,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
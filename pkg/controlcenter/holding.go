@@ -0,0 +1,140 @@
+package controlcenter
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+type HoldingStrategy string
+
+const (
+	// trams wait at timing stops until their scheduled departure
+	HOLDING_SCHEDULE HoldingStrategy = "schedule"
+	// trams wait at timing stops until the scheduled headway to the previous tram passes
+	HOLDING_FORWARD_HEADWAY HoldingStrategy = "forwardHeadway"
+	// trams wait at timing stops until they are halfway between the previous and the next tram
+	HOLDING_EVEN_HEADWAY HoldingStrategy = "evenHeadway"
+)
+
+// Dispatching control of a route. Trams of a controlled route are held only
+// at its timing stops, at other stops they depart when passengers are done boarding.
+type HoldingControl struct {
+	Route    string          `json:"route"`
+	Strategy HoldingStrategy `json:"strategy"`
+	// names of stop groups
+	TimingStops []string `json:"timingStops"`
+	// part of the scheduled headway kept to the previous tram, 1 if not given
+	HeadwayRatio float64 `json:"headwayRatio,omitempty"`
+	// max time in seconds a tram is held after arriving at the stop, unlimited if not given
+	MaxHoldingTime uint `json:"maxHoldingTime,omitempty"`
+}
+
+type holdingControl struct {
+	HoldingControl
+	timingStopIDs structs.Set[uint64]
+}
+
+// Times of trams of the same route at the stop, used for holding on headway.
+// Unknown times are set to 0.
+type Headways struct {
+	ArrivalTime       uint
+	ScheduledHeadway  uint
+	PreviousDeparture uint
+	NextArrival       uint
+}
+
+func (c *ControlCenter) SetHoldingControls(currentCity *city.City, controls []HoldingControl) error {
+	routeNames := structs.NewSet[string]()
+	for _, route := range currentCity.GetTramRoutes() {
+		routeNames.Add(route.Name)
+	}
+
+	stopsByName := currentCity.GetStopsByName()
+	holdingControls := make(map[string]*holdingControl, len(controls))
+
+	for _, control := range controls {
+		switch {
+		case !routeNames.Includes(control.Route):
+			return fmt.Errorf("route %q not found", control.Route)
+		case holdingControls[control.Route] != nil:
+			return fmt.Errorf("route %q has more than one holding control", control.Route)
+		case control.HeadwayRatio < 0:
+			return fmt.Errorf("route %q: headway ratio can't be negative", control.Route)
+		}
+
+		switch control.Strategy {
+		case HOLDING_SCHEDULE, HOLDING_FORWARD_HEADWAY, HOLDING_EVEN_HEADWAY:
+		default:
+			return fmt.Errorf("route %q: unknown holding strategy %q", control.Route, control.Strategy)
+		}
+
+		timingStopIDs := structs.NewSet[uint64]()
+		for _, stopName := range control.TimingStops {
+			group, ok := stopsByName[stopName]
+			if !ok {
+				return fmt.Errorf("route %q: stop group %q not found", control.Route, stopName)
+			}
+
+			for stopID := range group {
+				timingStopIDs.Add(stopID)
+			}
+		}
+
+		if control.HeadwayRatio == 0 {
+			control.HeadwayRatio = 1
+		}
+
+		holdingControls[control.Route] = &holdingControl{
+			HoldingControl: control,
+			timingStopIDs:  timingStopIDs,
+		}
+	}
+
+	c.holdingControls = holdingControls
+	return nil
+}
+
+func (c *ControlCenter) GetHoldingControls() []HoldingControl {
+	result := make([]HoldingControl, 0, len(c.holdingControls))
+	for _, routeName := range slices.Sorted(maps.Keys(c.holdingControls)) {
+		result = append(result, c.holdingControls[routeName].HoldingControl)
+	}
+	return result
+}
+
+// Returns true if trams of the route wait at the stop until their scheduled departure
+func (c *ControlCenter) IsHeldToSchedule(routeName string, stopID uint64) bool {
+	control, ok := c.holdingControls[routeName]
+	return !ok || control.Strategy == HOLDING_SCHEDULE && control.timingStopIDs.Includes(stopID)
+}
+
+// Returns true if trams of the route are held at the stop on headway
+func (c *ControlCenter) IsHeldOnHeadway(routeName string, stopID uint64) bool {
+	control, ok := c.holdingControls[routeName]
+	return ok && control.Strategy != HOLDING_SCHEDULE && control.timingStopIDs.Includes(stopID)
+}
+
+// Returns time until which the tram is held at the stop on headway, or 0 if it isn't held
+func (c *ControlCenter) GetHoldingTime(routeName string, stopID uint64, headways Headways) uint {
+	if !c.IsHeldOnHeadway(routeName, stopID) || headways.PreviousDeparture == 0 {
+		return 0
+	}
+
+	control := c.holdingControls[routeName]
+	holdingTime := headways.PreviousDeparture + uint(math.Round(control.HeadwayRatio*float64(headways.ScheduledHeadway)))
+
+	if control.Strategy == HOLDING_EVEN_HEADWAY && headways.NextArrival > headways.PreviousDeparture {
+		holdingTime = (headways.PreviousDeparture + headways.NextArrival) / 2
+	}
+
+	if control.MaxHoldingTime > 0 {
+		holdingTime = min(holdingTime, headways.ArrivalTime+control.MaxHoldingTime)
+	}
+
+	return holdingTime
+}
@@ -2,6 +2,7 @@ package controlcenter
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
@@ -19,7 +20,24 @@ func (p *Path) GetProgressForIndex(index int) float32 {
 	return p.TimePrefixSum[index] / p.TimePrefixSum[len(p.TimePrefixSum)-1]
 }
 
-func getShortestPath(nodesByID *map[uint64]graph.GraphNode, stops stopPair) (result Path) {
+// Decides if the edge between two adjacent nodes can't be used by the path
+type edgeFilter func(from, to graph.GraphNode) bool
+
+func newPath(nodes []graph.GraphNode) Path {
+	return Path{
+		Nodes:         nodes,
+		MaxSpeeds:     getMaxSpeeds(nodes),
+		TimePrefixSum: getPathTimePrefixSum(nodes),
+	}
+}
+
+// Finds the shortest path between nodes with A* algorithm, skipping edges
+// rejected by isForbidden (if given). Returns false if there is no such path.
+func getShortestPath(
+	nodesByID *map[uint64]graph.GraphNode,
+	stops stopPair,
+	isForbidden edgeFilter,
+) (result Path, ok bool) {
 	nodesToProcess := structs.NewPriorityQueueOrdered[uint64, float32]()
 	nodesToProcess.Push(stops.source, 0)
 
@@ -31,10 +49,7 @@ func getShortestPath(nodesByID *map[uint64]graph.GraphNode, stops stopPair) (res
 		currentID := nodesToProcess.Pop()
 
 		if currentID == stops.destination {
-			result.Nodes = reconstructPath(predecessors, nodesByID, currentID)
-			result.MaxSpeeds = getMaxSpeeds(result.Nodes)
-			result.TimePrefixSum = getPathTimePrefixSum(result.Nodes)
-			return
+			return newPath(reconstructPath(predecessors, nodesByID, currentID)), true
 		}
 
 		if visitedNodes.Includes(currentID) {
@@ -43,7 +58,16 @@ func getShortestPath(nodesByID *map[uint64]graph.GraphNode, stops stopPair) (res
 
 		visitedNodes.Add(currentID)
 
-		for _, neighbor := range (*nodesByID)[currentID].GetNeighbors() {
+		currentNode := (*nodesByID)[currentID]
+		neighbors := currentNode.GetNeighbors()
+
+		// Neighbors are visited in the order of their IDs to keep the results reproducible
+		for _, neighborID := range slices.Sorted(maps.Keys(neighbors)) {
+			neighbor := neighbors[neighborID]
+			if isForbidden != nil && isForbidden(currentNode, (*nodesByID)[neighbor.ID]) {
+				continue
+			}
+
 			tentativeDistance := tentativeDistFromSource[currentID] + neighbor.Distance
 			cost, wasVisited := tentativeDistFromSource[neighbor.ID]
 
@@ -61,7 +85,7 @@ func getShortestPath(nodesByID *map[uint64]graph.GraphNode, stops stopPair) (res
 		}
 	}
 
-	panic(fmt.Sprintf("No path found between %d and %d nodes", stops.source, stops.destination))
+	return Path{}, false
 }
 
 func reconstructPath(
@@ -128,3 +152,69 @@ func getPathTimePrefixSum(nodes []graph.GraphNode) []float32 {
 
 	return prefixSum
 }
+
+// Creates a path going through the nodes with the given IDs
+func (c *ControlCenter) GetPathFromNodeIDs(nodeIDs []uint64) (*Path, error) {
+	if len(nodeIDs) < 2 {
+		return nil, fmt.Errorf("path has to contain at least 2 nodes")
+	}
+
+	nodes := make([]graph.GraphNode, 0, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		node, ok := c.nodesByID[nodeID]
+		if !ok {
+			return nil, fmt.Errorf("node %d not found", nodeID)
+		}
+
+		if i > 0 {
+			if _, ok := nodes[i-1].GetNeighbors()[nodeID]; !ok {
+				return nil, fmt.Errorf("node %d is not a neighbor of node %d", nodeID, nodeIDs[i-1])
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	path := newPath(nodes)
+	return &path, nil
+}
+
+func isEdgeClosed(from, to graph.GraphNode) bool {
+	return to.IsClosed() || from.IsEdgeClosed(to.GetID())
+}
+
+// Returns true if any node or edge of the path after the given index is closed
+func (p *Path) IsClosedAfter(index int) bool {
+	for i := index; i < len(p.Nodes)-1; i++ {
+		if isEdgeClosed(p.Nodes[i], p.Nodes[i+1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Path) getPolyline() []Coordinates {
+	polyline := make([]Coordinates, 0, len(p.Nodes))
+	for _, node := range p.Nodes {
+		lat, lon := node.GetCoordinates()
+		polyline = append(polyline, Coordinates{Lat: lat, Lon: lon})
+	}
+
+	return polyline
+}
+
+// Finds the shortest path between any two nodes, e.g. for trams running between
+// a depot and a terminal. Returns false if there is no such path.
+func (c *ControlCenter) FindPath(sourceNodeID, destinationNodeID uint64) (*Path, bool) {
+	if sourceNodeID == destinationNodeID {
+		return nil, false
+	}
+
+	path, ok := getShortestPath(&c.nodesByID, stopPair{source: sourceNodeID, destination: destinationNodeID}, nil)
+	if !ok {
+		return nil, false
+	}
+
+	return &path, true
+}
@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
+)
+
+// Runs without passengers of a block's vehicle from the depot to the first
+// stop of the block and from the last stop of the block back to the depot
+type deadRuns struct {
+	depotID     uint
+	depotNodeID uint64
+	pullOut     *controlcenter.Path
+	pullIn      *controlcenter.Path
+	pullOutTime uint
+}
+
+type nodePair struct {
+	source, destination uint64
+}
+
+// Assigns blocks to the nearest depots with free stabling tracks in the order of their first departure.
+// Vehicles of blocks which don't fit into any depot start and end their blocks at terminals.
+func (s *Simulation) planDeadRuns() {
+	s.deadRuns = make(map[uint]deadRuns)
+	s.stabledVehicles = make(map[uint]uint, len(s.depots))
+
+	if len(s.depots) == 0 {
+		return
+	}
+
+	paths := make(map[nodePair]*controlcenter.Path)
+	findPath := func(sourceNodeID, destinationNodeID uint64) *controlcenter.Path {
+		key := nodePair{source: sourceNodeID, destination: destinationNodeID}
+		if path, ok := paths[key]; ok {
+			return path
+		}
+
+		path, _ := s.controlCenter.FindPath(sourceNodeID, destinationNodeID)
+		paths[key] = path
+		return path
+	}
+
+	blocks := slices.Clone(s.blocks)
+	slices.SortStableFunc(blocks, func(b1, b2 vehicle.Block) int {
+		return cmp.Compare(s.city.GetTripByID(b1.TripIDs[0]).Stops[0].Time, s.city.GetTripByID(b2.TripIDs[0]).Stops[0].Time)
+	})
+
+	var outstabledCount int
+	for _, block := range blocks {
+		firstStop := s.city.GetTripByID(block.TripIDs[0]).Stops[0]
+		lastTrip := s.city.GetTripByID(block.TripIDs[len(block.TripIDs)-1])
+		lastStop := lastTrip.Stops[len(lastTrip.Stops)-1]
+
+		var best *deadRuns
+		for _, depot := range s.depots {
+			if s.stabledVehicles[depot.ID] >= depot.StablingTracks {
+				continue
+			}
+
+			pullOut, pullIn := findPath(depot.NodeID, firstStop.ID), findPath(lastStop.ID, depot.NodeID)
+			if pullOut == nil || pullIn == nil {
+				continue
+			}
+
+			if best == nil || getPathTime(pullOut) < getPathTime(best.pullOut) {
+				best = &deadRuns{depotID: depot.ID, depotNodeID: depot.NodeID, pullOut: pullOut, pullIn: pullIn}
+			}
+		}
+
+		if best == nil {
+			outstabledCount++
+			continue
+		}
+
+		pullOutDuration := uint(math.Ceil(float64(getPathTime(best.pullOut)))) + vehicle.PULL_OUT_MARGIN
+		best.pullOutTime = firstStop.Time - min(firstStop.Time, pullOutDuration)
+
+		s.deadRuns[block.ID] = *best
+		s.stabledVehicles[best.depotID]++
+	}
+
+	if outstabledCount > 0 {
+		log.Default().Printf("%d vehicles don't fit into depots and are stabled at terminals", outstabledCount)
+	}
+}
+
+// Returns travel time along the path at max speed
+func getPathTime(path *controlcenter.Path) float32 {
+	return path.TimePrefixSum[len(path.TimePrefixSum)-1]
+}
+
+// Returns time bounds of the schedule, extended by pull-outs from depots before the first trip
+func (s *Simulation) GetTimeBounds() city.TimeBounds {
+	timeBounds := s.city.GetTimeBounds()
+	for _, deadRuns := range s.deadRuns {
+		timeBounds.StartTime = min(timeBounds.StartTime, deadRuns.pullOutTime)
+	}
+	return timeBounds
+}
+
+func (s *Simulation) GetDepots() []vehicle.Depot {
+	return s.depots
+}
+
+// Returns changes in the number of trams stabled at the depot until the current time
+func (s *Simulation) GetDepotOccupancy(depotID uint) []vehicle.DepotOccupancy {
+	var movements []vehicle.DepotMovement
+	for _, tram := range s.trams {
+		if tramDepotID, tramMovements := tram.GetDepotMovements(); tramDepotID == depotID {
+			movements = append(movements, tramMovements...)
+		}
+	}
+
+	return vehicle.GetDepotOccupancy(s.stabledVehicles[depotID], movements)
+}
+
+func (s *Simulation) depotOccupancyToCSVBuffer(writer io.Writer) error {
+	writer.Write([]byte("depot_id,time,vehicle_count,stabling_tracks\n"))
+
+	for _, depot := range s.depots {
+		for _, occupancy := range s.GetDepotOccupancy(depot.ID) {
+			_, err := fmt.Fprintf(
+				writer,
+				"%d,%d,%d,%d\n",
+				depot.ID,
+				occupancy.Time,
+				occupancy.VehicleCount,
+				depot.StablingTracks,
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
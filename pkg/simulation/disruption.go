@@ -0,0 +1,24 @@
+package simulation
+
+import "github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+
+// Schedules closure of a node or an edge, taking effect from the next step.
+// Returns error message or empty string on success.
+func (s *Simulation) AddDisruption(disruption controlcenter.Disruption) string {
+	if _, err := s.controlCenter.AddDisruption(disruption); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// Removes the disruption, taking effect from the next step
+func (s *Simulation) RemoveDisruption(id uint) string {
+	if err := s.controlCenter.RemoveDisruption(id); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (s *Simulation) GetDisruptions() []controlcenter.Disruption {
+	return s.controlCenter.GetDisruptions()
+}
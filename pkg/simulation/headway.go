@@ -0,0 +1,50 @@
+package simulation
+
+import (
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/headway"
+)
+
+func (s *Simulation) getStopVisits(route *trip.TramRoute) []headway.StopVisit {
+	visits := make([]headway.StopVisit, 0)
+
+	for _, tramTrip := range route.Trips {
+		tram := s.trams[tramTrip.ID]
+		for stopIndex, stop := range tramTrip.Stops {
+			arrivalTime, isArrived := tram.GetActualArrival(stopIndex)
+			visits = append(visits, headway.StopVisit{
+				TramID:        tram.ID,
+				Direction:     tramTrip.TripHeadSign,
+				StopID:        stop.ID,
+				StopIndex:     stopIndex,
+				ScheduledTime: stop.Time,
+				ArrivalTime:   arrivalTime,
+				IsArrived:     isArrived,
+			})
+		}
+	}
+
+	return visits
+}
+
+// Returns headway regularity statistics of the route's trams at its stops until the current time
+func (s *Simulation) GetRouteHeadwayStats(routeName string) headway.RouteHeadwayStats {
+	for _, route := range s.city.GetTramRoutes() {
+		if route.Name == routeName {
+			return headway.GetRouteHeadwayStats(route.Name, s.getStopVisits(&route), s.time)
+		}
+	}
+
+	return headway.RouteHeadwayStats{Route: routeName, Directions: make([]headway.DirectionHeadwayStats, 0)}
+}
+
+func (s *Simulation) getHeadwayStats() []headway.RouteHeadwayStats {
+	tramRoutes := s.city.GetTramRoutes()
+	result := make([]headway.RouteHeadwayStats, 0, len(tramRoutes))
+
+	for _, route := range tramRoutes {
+		result = append(result, headway.GetRouteHeadwayStats(route.Name, s.getStopVisits(&route), s.time))
+	}
+
+	return result
+}
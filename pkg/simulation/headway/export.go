@@ -0,0 +1,64 @@
+package headway
+
+import (
+	"fmt"
+	"io"
+)
+
+func HeadwaysToCSVBuffer(routeStats []RouteHeadwayStats, writer io.Writer) error {
+	writer.Write([]byte("route,direction,stop_id,headway_count,mean_headway,mean_scheduled_headway,coefficient_of_variation,bunching_count,excess_waiting_time\n"))
+
+	for _, route := range routeStats {
+		for _, direction := range route.Directions {
+			for _, stop := range direction.Stops {
+				_, err := fmt.Fprintf(
+					writer,
+					"%s,%q,%d,%d,%.3f,%.3f,%.3f,%d,%.3f\n",
+					route.Route,
+					direction.Direction,
+					stop.StopID,
+					stop.HeadwayCount,
+					stop.MeanHeadway,
+					stop.MeanScheduledHeadway,
+					stop.CoefficientOfVariation,
+					stop.BunchingCount,
+					stop.ExcessWaitingTime,
+				)
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func BunchingEventsToCSVBuffer(routeStats []RouteHeadwayStats, writer io.Writer) error {
+	writer.Write([]byte("route,stop_id,tram_id,previous_tram_id,time,headway,scheduled_headway\n"))
+
+	for _, route := range routeStats {
+		for _, direction := range route.Directions {
+			for _, event := range direction.BunchingEvents {
+				_, err := fmt.Fprintf(
+					writer,
+					"%s,%d,%d,%d,%d,%d,%d\n",
+					route.Route,
+					event.StopID,
+					event.TramID,
+					event.PreviousTramID,
+					event.Time,
+					event.Headway,
+					event.ScheduledHeadway,
+				)
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
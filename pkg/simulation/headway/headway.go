@@ -0,0 +1,223 @@
+package headway
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Headway shorter than this part of the scheduled headway is a bunching event
+const BUNCHING_HEADWAY_RATIO = 0.25
+
+// Scheduled and actual arrival of a trip of the route at one of its stops
+type StopVisit struct {
+	TramID        uint
+	Direction     string
+	StopID        uint64
+	StopIndex     int
+	ScheduledTime uint
+	ArrivalTime   uint
+	IsArrived     bool
+}
+
+// Tram arriving at the stop too soon after the previous tram of the route
+type BunchingEvent struct {
+	StopID           uint64 `json:"stopID"`
+	TramID           uint   `json:"tramID"`
+	PreviousTramID   uint   `json:"previousTramID"`
+	Time             uint   `json:"time"`
+	Headway          uint   `json:"headway"`
+	ScheduledHeadway uint   `json:"scheduledHeadway"`
+}
+
+// Excess waiting time is the difference between the average waiting time of passengers
+// arriving at the stop at random with the actual and the scheduled headways.
+type StopHeadwayStats struct {
+	StopID                 uint64  `json:"stopID"`
+	HeadwayCount           int     `json:"headwayCount"`
+	MeanHeadway            float64 `json:"meanHeadway"`
+	MeanScheduledHeadway   float64 `json:"meanScheduledHeadway"`
+	CoefficientOfVariation float64 `json:"coefficientOfVariation"`
+	BunchingCount          int     `json:"bunchingCount"`
+	ExcessWaitingTime      float64 `json:"excessWaitingTime"`
+}
+
+// Statistics of stops served in the direction, averaged over stops weighted by their headway count
+type DirectionHeadwayStats struct {
+	Direction              string             `json:"direction"`
+	HeadwayCount           int                `json:"headwayCount"`
+	CoefficientOfVariation float64            `json:"coefficientOfVariation"`
+	BunchingCount          int                `json:"bunchingCount"`
+	ExcessWaitingTime      float64            `json:"excessWaitingTime"`
+	Stops                  []StopHeadwayStats `json:"stops"`
+	BunchingEvents         []BunchingEvent    `json:"bunchingEvents"`
+}
+
+type RouteHeadwayStats struct {
+	Route      string                  `json:"route"`
+	Directions []DirectionHeadwayStats `json:"directions"`
+}
+
+// Returns headway statistics of the route from visits of its trips at stops until the given time.
+// Headways are computed between visits of trips of the same direction (trip head sign),
+// so stops served in both directions, like termini, are counted in each of them separately.
+func GetRouteHeadwayStats(route string, visits []StopVisit, time uint) RouteHeadwayStats {
+	visitsByDirection := make(map[string]map[uint64][]StopVisit)
+	for _, visit := range visits {
+		if _, ok := visitsByDirection[visit.Direction]; !ok {
+			visitsByDirection[visit.Direction] = make(map[uint64][]StopVisit)
+		}
+		visitsByDirection[visit.Direction][visit.StopID] = append(visitsByDirection[visit.Direction][visit.StopID], visit)
+	}
+
+	result := RouteHeadwayStats{
+		Route:      route,
+		Directions: make([]DirectionHeadwayStats, 0, len(visitsByDirection)),
+	}
+
+	for _, direction := range slices.Sorted(maps.Keys(visitsByDirection)) {
+		visitsByStopID := visitsByDirection[direction]
+		stopIDs := slices.SortedFunc(maps.Keys(visitsByStopID), func(id1, id2 uint64) int {
+			return cmp.Or(
+				cmp.Compare(getMinStopIndex(visitsByStopID[id1]), getMinStopIndex(visitsByStopID[id2])),
+				cmp.Compare(id1, id2),
+			)
+		})
+
+		directionStats := DirectionHeadwayStats{
+			Direction:      direction,
+			Stops:          make([]StopHeadwayStats, 0, len(stopIDs)),
+			BunchingEvents: make([]BunchingEvent, 0),
+		}
+
+		for _, stopID := range stopIDs {
+			stopStats, events := getStopHeadwayStats(stopID, visitsByStopID[stopID], time)
+			directionStats.Stops = append(directionStats.Stops, stopStats)
+			directionStats.BunchingEvents = append(directionStats.BunchingEvents, events...)
+
+			directionStats.HeadwayCount += stopStats.HeadwayCount
+			directionStats.BunchingCount += stopStats.BunchingCount
+			directionStats.CoefficientOfVariation += stopStats.CoefficientOfVariation * float64(stopStats.HeadwayCount)
+			directionStats.ExcessWaitingTime += stopStats.ExcessWaitingTime * float64(stopStats.HeadwayCount)
+		}
+
+		if directionStats.HeadwayCount > 0 {
+			directionStats.CoefficientOfVariation /= float64(directionStats.HeadwayCount)
+			directionStats.ExcessWaitingTime /= float64(directionStats.HeadwayCount)
+		}
+
+		slices.SortFunc(directionStats.BunchingEvents, func(e1, e2 BunchingEvent) int {
+			return cmp.Or(cmp.Compare(e1.Time, e2.Time), cmp.Compare(e1.TramID, e2.TramID))
+		})
+
+		result.Directions = append(result.Directions, directionStats)
+	}
+
+	return result
+}
+
+func getMinStopIndex(visits []StopVisit) int {
+	return slices.MinFunc(visits, func(v1, v2 StopVisit) int {
+		return cmp.Compare(v1.StopIndex, v2.StopIndex)
+	}).StopIndex
+}
+
+func getStopHeadwayStats(stopID uint64, visits []StopVisit, time uint) (StopHeadwayStats, []BunchingEvent) {
+	stats := StopHeadwayStats{StopID: stopID}
+	events := make([]BunchingEvent, 0)
+
+	// Scheduled headway of a trip is the time since the trip scheduled before it
+	slices.SortFunc(visits, func(v1, v2 StopVisit) int {
+		return cmp.Or(cmp.Compare(v1.ScheduledTime, v2.ScheduledTime), cmp.Compare(v1.TramID, v2.TramID))
+	})
+
+	scheduledHeadwayByTramID := make(map[uint]uint, len(visits))
+	scheduledHeadways := make([]uint, 0, len(visits))
+	for i := 1; i < len(visits) && visits[i].ScheduledTime <= time; i++ {
+		scheduledHeadway := visits[i].ScheduledTime - visits[i-1].ScheduledTime
+		scheduledHeadwayByTramID[visits[i].TramID] = scheduledHeadway
+		scheduledHeadways = append(scheduledHeadways, scheduledHeadway)
+	}
+
+	arrivals := make([]StopVisit, 0, len(visits))
+	for _, visit := range visits {
+		if visit.IsArrived {
+			arrivals = append(arrivals, visit)
+		}
+	}
+
+	slices.SortFunc(arrivals, func(v1, v2 StopVisit) int {
+		return cmp.Or(cmp.Compare(v1.ArrivalTime, v2.ArrivalTime), cmp.Compare(v1.TramID, v2.TramID))
+	})
+
+	headways := make([]uint, 0, len(arrivals))
+	for i := 1; i < len(arrivals); i++ {
+		headway := arrivals[i].ArrivalTime - arrivals[i-1].ArrivalTime
+		headways = append(headways, headway)
+
+		scheduledHeadway, ok := scheduledHeadwayByTramID[arrivals[i].TramID]
+		if ok && float64(headway) < BUNCHING_HEADWAY_RATIO*float64(scheduledHeadway) {
+			events = append(events, BunchingEvent{
+				StopID:           stopID,
+				TramID:           arrivals[i].TramID,
+				PreviousTramID:   arrivals[i-1].TramID,
+				Time:             arrivals[i].ArrivalTime,
+				Headway:          headway,
+				ScheduledHeadway: scheduledHeadway,
+			})
+		}
+	}
+
+	stats.HeadwayCount = len(headways)
+	stats.BunchingCount = len(events)
+	stats.MeanHeadway = getMean(headways)
+	stats.MeanScheduledHeadway = getMean(scheduledHeadways)
+
+	if stats.MeanHeadway > 0 {
+		stats.CoefficientOfVariation = getStandardDeviation(headways, stats.MeanHeadway) / stats.MeanHeadway
+	}
+
+	if len(headways) > 0 && len(scheduledHeadways) > 0 {
+		stats.ExcessWaitingTime = getAverageWaitingTime(headways) - getAverageWaitingTime(scheduledHeadways)
+	}
+
+	return stats, events
+}
+
+func getMean(values []uint) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += float64(value)
+	}
+
+	return sum / float64(len(values))
+}
+
+func getStandardDeviation(values []uint, mean float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += (float64(value) - mean) * (float64(value) - mean)
+	}
+
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// Returns average waiting time of passengers arriving at random for trams with the given headways
+func getAverageWaitingTime(headways []uint) float64 {
+	var sum, sumOfSquares float64
+	for _, headway := range headways {
+		sum += float64(headway)
+		sumOfSquares += float64(headway) * float64(headway)
+	}
+
+	if sum == 0 {
+		return 0
+	}
+
+	return sumOfSquares / (2 * sum)
+}
@@ -0,0 +1,56 @@
+package simulation
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+)
+
+type tramAtStop struct {
+	tram      *tram.Tram
+	stopIndex int
+}
+
+// Sets holding control strategies of routes, replacing the previous ones.
+// Returns error message or empty string on success.
+func (s *Simulation) SetHoldingControls(controls []controlcenter.HoldingControl) string {
+	if err := s.controlCenter.SetHoldingControls(s.city, controls); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (s *Simulation) GetHoldingControls() []controlcenter.HoldingControl {
+	return s.controlCenter.GetHoldingControls()
+}
+
+// Links trams with the previous trams of the same route at each of their stops
+func (s *Simulation) linkTramsAtStops(trams map[uint]*tram.Tram) {
+	for _, route := range s.city.GetTramRoutes() {
+		tramsByStopID := make(map[uint64][]tramAtStop)
+		for _, trip := range route.Trips {
+			for stopIndex, stop := range trip.Stops {
+				tramsByStopID[stop.ID] = append(tramsByStopID[stop.ID], tramAtStop{
+					tram:      trams[trip.ID],
+					stopIndex: stopIndex,
+				})
+			}
+		}
+
+		for _, tramsAtStop := range tramsByStopID {
+			slices.SortFunc(tramsAtStop, func(t1, t2 tramAtStop) int {
+				return cmp.Or(
+					cmp.Compare(t1.tram.TripDetails.Trip.Stops[t1.stopIndex].Time, t2.tram.TripDetails.Trip.Stops[t2.stopIndex].Time),
+					cmp.Compare(t1.tram.ID, t2.tram.ID),
+				)
+			})
+
+			for i := 1; i < len(tramsAtStop); i++ {
+				previous, current := tramsAtStop[i-1], tramsAtStop[i]
+				current.tram.LinkPreviousTramAtStop(current.stopIndex, previous.tram, previous.stopIndex)
+			}
+		}
+	}
+}
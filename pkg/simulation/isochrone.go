@@ -0,0 +1,86 @@
+package simulation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+type IsochronePointGeometry struct {
+	Type string `json:"type"`
+	// longitude and latitude, in the GeoJSON order
+	Coordinates [2]float32 `json:"coordinates"`
+}
+
+type IsochroneFeatureProperties struct {
+	StopID      uint64 `json:"stopID"`
+	StopName    string `json:"stopName"`
+	ArrivalTime uint   `json:"arrivalTime"`
+	TravelTime  uint   `json:"travelTime"`
+}
+
+type IsochroneFeature struct {
+	Type       string                     `json:"type"`
+	Geometry   IsochronePointGeometry     `json:"geometry"`
+	Properties IsochroneFeatureProperties `json:"properties"`
+}
+
+// GeoJSON feature collection of stop points with travel times
+type IsochroneFeatureCollection struct {
+	Type     string             `json:"type"`
+	Features []IsochroneFeature `json:"features"`
+}
+
+// Stops reachable from a stop group within the maximum travel time, with the earliest arrivals
+type Isochrone struct {
+	From          string                     `json:"from"`
+	DepartureTime uint                       `json:"departureTime"`
+	MaxTravelTime uint                       `json:"maxTravelTime"`
+	Arrivals      []travelplan.StopArrival   `json:"arrivals"`
+	GeoJSON       IsochroneFeatureCollection `json:"geoJSON"`
+}
+
+// Returns stops reachable from the stop group within the given number of minutes
+// when leaving at the time, following planned arrivals of trips
+func (s *Simulation) GetIsochrone(fromGroup string, time uint, maxMinutes uint) (Isochrone, error) {
+	fromStops, ok := s.city.GetStopsByName()[fromGroup]
+	if !ok {
+		return Isochrone{}, fmt.Errorf("stop group %q not found", fromGroup)
+	}
+
+	isochrone := Isochrone{
+		From:          fromGroup,
+		DepartureTime: time,
+		MaxTravelTime: maxMinutes * 60,
+		GeoJSON: IsochroneFeatureCollection{
+			Type: "FeatureCollection",
+		},
+	}
+
+	isochrone.Arrivals = travelplan.GetEarliestArrivals(s.city, slices.Sorted(maps.Keys(fromStops)), time, isochrone.MaxTravelTime)
+	isochrone.GeoJSON.Features = make([]IsochroneFeature, 0, len(isochrone.Arrivals))
+
+	stopsByID := s.city.GetStopsByID()
+	for _, arrival := range isochrone.Arrivals {
+		stop := stopsByID[arrival.StopID]
+		lat, lon := stop.GetCoordinates()
+
+		isochrone.GeoJSON.Features = append(isochrone.GeoJSON.Features, IsochroneFeature{
+			Type: "Feature",
+			Geometry: IsochronePointGeometry{
+				Type:        "Point",
+				Coordinates: [2]float32{lon, lat},
+			},
+			Properties: IsochroneFeatureProperties{
+				StopID:      arrival.StopID,
+				StopName:    stop.GetName(),
+				ArrivalTime: arrival.ArrivalTime,
+				TravelTime:  arrival.TravelTime,
+			},
+		})
+	}
+
+	return isochrone, nil
+}
@@ -0,0 +1,145 @@
+package simulation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+// Trip of an itinerary with the stops of boarding and alighting
+type ItineraryLeg struct {
+	TramID         uint   `json:"tramID"`
+	Route          string `json:"route"`
+	TripHeadSign   string `json:"tripHeadSign"`
+	BoardStopID    uint64 `json:"boardStopID"`
+	BoardStopName  string `json:"boardStopName"`
+	DepartureTime  uint   `json:"departureTime"`
+	AlightStopID   uint64 `json:"alightStopID"`
+	AlightStopName string `json:"alightStopName"`
+	ArrivalTime    uint   `json:"arrivalTime"`
+	// time between the arrival of the previous leg and the departure, 0 for the first leg
+	TransferTime uint `json:"transferTime"`
+}
+
+// Journey planned between stop groups, with its legs described line by line in Steps
+type Itinerary struct {
+	From          string                        `json:"from"`
+	To            string                        `json:"to"`
+	Strategy      travelplan.TravelPlanStrategy `json:"strategy"`
+	DepartureTime uint                          `json:"departureTime"`
+	ArrivalTime   uint                          `json:"arrivalTime"`
+	Transfers     uint                          `json:"transfers"`
+	Legs          []ItineraryLeg                `json:"legs"`
+	Steps         []string                      `json:"steps"`
+}
+
+// Plans journey between the stop groups departing not earlier than the time,
+// the same way as travel plans of passengers with the strategy are created
+func (s *Simulation) PlanJourney(fromGroup, toGroup string, time uint, strategy string) (Itinerary, error) {
+	travelPlanStrategy := travelplan.TravelPlanStrategy(strings.ToUpper(strategy))
+	if !slices.Contains([]travelplan.TravelPlanStrategy{travelplan.ASAP, travelplan.COMFORT, travelplan.SURE}, travelPlanStrategy) {
+		return Itinerary{}, fmt.Errorf("unknown strategy %q, expected ASAP, COMFORT or SURE", strategy)
+	}
+
+	if fromGroup == toGroup {
+		return Itinerary{}, fmt.Errorf("journey must end at another stop group")
+	}
+
+	stopsByName := s.city.GetStopsByName()
+	fromStops, ok := stopsByName[fromGroup]
+	if !ok {
+		return Itinerary{}, fmt.Errorf("stop group %q not found", fromGroup)
+	}
+
+	toStops, ok := stopsByName[toGroup]
+	if !ok {
+		return Itinerary{}, fmt.Errorf("stop group %q not found", toGroup)
+	}
+
+	travelPlan, ok := travelplan.GetTravelPlan(
+		s.city,
+		travelPlanStrategy,
+		slices.Sorted(maps.Keys(fromStops)),
+		slices.Sorted(maps.Keys(toStops)),
+		time,
+		nil,
+		nil,
+	)
+	if !ok {
+		return Itinerary{}, fmt.Errorf("no journey from %q to %q found", fromGroup, toGroup)
+	}
+
+	itinerary := Itinerary{
+		From:     fromGroup,
+		To:       toGroup,
+		Strategy: travelPlanStrategy,
+		Legs:     make([]ItineraryLeg, 0),
+		Steps:    make([]string, 0),
+	}
+
+	stopsByID := s.city.GetStopsByID()
+	for i, leg := range travelPlan.GetJourney() {
+		tramTrip := s.city.GetTripByID(leg.TripID)
+		itineraryLeg := ItineraryLeg{
+			TramID:         leg.TripID,
+			Route:          s.getRouteName(leg.TripID),
+			TripHeadSign:   tramTrip.TripHeadSign,
+			BoardStopID:    leg.StartStopID,
+			BoardStopName:  stopsByID[leg.StartStopID].GetName(),
+			DepartureTime:  leg.DepartureTime,
+			AlightStopID:   leg.EndStopID,
+			AlightStopName: stopsByID[leg.EndStopID].GetName(),
+			ArrivalTime:    leg.ArrivalTime,
+		}
+
+		if i > 0 {
+			previousLeg := itinerary.Legs[i-1]
+			itineraryLeg.TransferTime = itineraryLeg.DepartureTime - previousLeg.ArrivalTime
+
+			step := fmt.Sprintf("Transfer at %s", previousLeg.AlightStopName)
+			if previousLeg.AlightStopID != itineraryLeg.BoardStopID {
+				step += fmt.Sprintf(" from stop %d to stop %d", previousLeg.AlightStopID, itineraryLeg.BoardStopID)
+			}
+			itinerary.Steps = append(itinerary.Steps, fmt.Sprintf("%s, %d min", step, itineraryLeg.TransferTime/60))
+		}
+
+		itinerary.Legs = append(itinerary.Legs, itineraryLeg)
+		itinerary.Steps = append(itinerary.Steps, fmt.Sprintf(
+			"%s %s → %s: board at %s (stop %d), get off at %s %s (stop %d)",
+			formatTime(itineraryLeg.DepartureTime),
+			itineraryLeg.Route,
+			itineraryLeg.TripHeadSign,
+			itineraryLeg.BoardStopName,
+			itineraryLeg.BoardStopID,
+			formatTime(itineraryLeg.ArrivalTime),
+			itineraryLeg.AlightStopName,
+			itineraryLeg.AlightStopID,
+		))
+	}
+
+	itinerary.DepartureTime = itinerary.Legs[0].DepartureTime
+	itinerary.ArrivalTime = itinerary.Legs[len(itinerary.Legs)-1].ArrivalTime
+	itinerary.Transfers = uint(len(itinerary.Legs) - 1)
+
+	return itinerary, nil
+}
+
+func (s *Simulation) getRouteName(tripID uint) string {
+	for _, route := range s.city.GetTramRoutes() {
+		for _, tramTrip := range route.Trips {
+			if tramTrip.ID == tripID {
+				return route.Name
+			}
+		}
+	}
+
+	panic(fmt.Sprintf("Trip with ID %d not found", tripID))
+}
+
+// Formats seconds since midnight in HH:MM:SS format
+func formatTime(time uint) string {
+	return fmt.Sprintf("%02d:%02d:%02d", time/3600, time/60%60, time%60)
+}
@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/loadprofile"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+)
+
+func getLoadProfileTrip(t *tram.Tram, rides []passenger.Ride) loadprofile.Trip {
+	stops := t.TripDetails.Trip.Stops
+	isVisited := make([]bool, len(stops))
+
+	for stopIndex := range stops {
+		if stopIndex == len(stops)-1 {
+			_, isVisited[stopIndex] = t.GetActualArrival(stopIndex)
+		} else {
+			_, isVisited[stopIndex] = t.GetActualDeparture(stopIndex)
+		}
+	}
+
+	return loadprofile.Trip{
+		ID:           t.ID,
+		Route:        t.Route.Name,
+		Direction:    t.TripDetails.Trip.TripHeadSign,
+		Capacity:     t.VehicleType.GetCapacity(),
+		Stops:        stops,
+		IsVisited:    isVisited,
+		CurrentIndex: t.TripDetails.Index,
+		Rides:        rides,
+	}
+}
+
+func (s *Simulation) getRouteLoadProfiles(routeName string, ridesByTramID map[uint][]passenger.Ride) []loadprofile.RouteLoadProfile {
+	trips := make([]loadprofile.Trip, 0)
+	for _, route := range s.city.GetTramRoutes() {
+		if route.Name != routeName {
+			continue
+		}
+
+		for _, tramTrip := range route.Trips {
+			trips = append(trips, getLoadProfileTrip(s.trams[tramTrip.ID], ridesByTramID[tramTrip.ID]))
+		}
+	}
+
+	return loadprofile.GetRouteLoadProfiles(routeName, trips)
+}
+
+// Returns passenger load of trams of the route between consecutive stops,
+// in each direction and hour, until the current time
+func (s *Simulation) GetRouteLoadProfiles(routeName string) []loadprofile.RouteLoadProfile {
+	return s.getRouteLoadProfiles(routeName, s.passengersStore.GetRidesByTramID())
+}
+
+func (s *Simulation) GetTripLoadProfile(tramID uint) loadprofile.TripLoadProfile {
+	tram, ok := s.trams[tramID]
+	if !ok {
+		panic(fmt.Sprintf("GetTripLoadProfile: tram with ID %d not found", tramID))
+	}
+
+	trip := getLoadProfileTrip(tram, s.passengersStore.GetRidesByTramID()[tramID])
+	return loadprofile.GetTripLoadProfile(&trip)
+}
+
+func (s *Simulation) getLoadProfiles() ([]loadprofile.RouteLoadProfile, []loadprofile.TripLoadProfile) {
+	ridesByTramID := s.passengersStore.GetRidesByTramID()
+	routeProfiles := make([]loadprofile.RouteLoadProfile, 0)
+	tripProfiles := make([]loadprofile.TripLoadProfile, 0, len(s.trams))
+
+	for _, route := range s.city.GetTramRoutes() {
+		routeProfiles = append(routeProfiles, s.getRouteLoadProfiles(route.Name, ridesByTramID)...)
+
+		for _, tramTrip := range route.Trips {
+			trip := getLoadProfileTrip(s.trams[tramTrip.ID], ridesByTramID[tramTrip.ID])
+			tripProfiles = append(tripProfiles, loadprofile.GetTripLoadProfile(&trip))
+		}
+	}
+
+	return routeProfiles, tripProfiles
+}
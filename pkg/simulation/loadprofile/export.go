@@ -0,0 +1,65 @@
+package loadprofile
+
+import (
+	"fmt"
+	"io"
+)
+
+func RouteLoadProfilesToCSVBuffer(profiles []RouteLoadProfile, writer io.Writer) error {
+	writer.Write([]byte("route,direction,hour,stop_id,next_stop_id,trip_count,boardings,alightings,mean_load,max_load,load_factor,is_peak_load\n"))
+
+	for _, profile := range profiles {
+		for i, stop := range profile.Stops {
+			_, err := fmt.Fprintf(
+				writer,
+				"%s,%q,%d,%d,%d,%d,%d,%d,%.3f,%d,%.3f,%t\n",
+				profile.Route,
+				profile.Direction,
+				profile.Hour,
+				stop.StopID,
+				stop.NextStopID,
+				stop.TripCount,
+				stop.Boardings,
+				stop.Alightings,
+				stop.MeanLoad,
+				stop.MaxLoad,
+				stop.LoadFactor,
+				i == profile.PeakLoadStopIndex,
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func TripLoadProfilesToCSVBuffer(profiles []TripLoadProfile, writer io.Writer) error {
+	writer.Write([]byte("trip_id,route,stop_id,stop_index,boardings,alightings,load,capacity,is_peak_load\n"))
+
+	for _, profile := range profiles {
+		for i, stop := range profile.Stops {
+			_, err := fmt.Fprintf(
+				writer,
+				"%d,%s,%d,%d,%d,%d,%d,%d,%t\n",
+				profile.TripID,
+				profile.Route,
+				stop.StopID,
+				i,
+				stop.Boardings,
+				stop.Alightings,
+				stop.Load,
+				profile.Capacity,
+				i == profile.PeakLoadStopIndex,
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package loadprofile
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/api"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+)
+
+// Trip of a tram with rides of passengers who took it. Passengers still in the tram
+// are counted until the stop with the current index, where the tram is heading.
+// Only stops the tram departed from, or arrived at for the last stop, are visited.
+type Trip struct {
+	ID        uint
+	Route     string
+	Direction string
+	// 0 if the capacity is unlimited
+	Capacity     uint
+	Stops        []api.ResponseTramTripStop
+	IsVisited    []bool
+	CurrentIndex int
+	Rides        []passenger.Ride
+}
+
+// Load is the number of passengers in the tram departing from the stop
+type TripStopLoad struct {
+	StopID     uint64 `json:"stopID"`
+	Boardings  uint   `json:"boardings"`
+	Alightings uint   `json:"alightings"`
+	Load       uint   `json:"load"`
+}
+
+// Peak load stop is the stop the tram departs from with the most passengers
+type TripLoadProfile struct {
+	TripID            uint           `json:"tripID"`
+	Route             string         `json:"route"`
+	Capacity          uint           `json:"capacity"`
+	Stops             []TripStopLoad `json:"stops"`
+	PeakLoadStopIndex int            `json:"peakLoadStopIndex"`
+}
+
+// Load of trams departing from the stop to the next one, next stop ID is 0 for last stops of trips.
+// Load factor is the ratio of the total load to the total capacity of trams, trams with
+// unlimited capacity aren't included in it.
+type StopLoad struct {
+	StopID     uint64  `json:"stopID"`
+	NextStopID uint64  `json:"nextStopID"`
+	TripCount  uint    `json:"tripCount"`
+	Boardings  uint    `json:"boardings"`
+	Alightings uint    `json:"alightings"`
+	MeanLoad   float64 `json:"meanLoad"`
+	MaxLoad    uint    `json:"maxLoad"`
+	LoadFactor float64 `json:"loadFactor"`
+}
+
+// Load of trams of the route going in the direction, grouped by the hour of scheduled departure from stops.
+// Peak load stop is the one with the highest mean load.
+type RouteLoadProfile struct {
+	Route             string     `json:"route"`
+	Direction         string     `json:"direction"`
+	Hour              uint       `json:"hour"`
+	Stops             []StopLoad `json:"stops"`
+	PeakLoadStopIndex int        `json:"peakLoadStopIndex"`
+}
+
+type stopLoadKey struct {
+	direction          string
+	hour               uint
+	stopID, nextStopID uint64
+}
+
+type stopLoadSum struct {
+	StopLoad
+	stopIndex     int
+	totalLoad     uint
+	limitedLoad   uint
+	totalCapacity uint
+}
+
+func GetTripLoadProfile(trip *Trip) TripLoadProfile {
+	profile := TripLoadProfile{
+		TripID:   trip.ID,
+		Route:    trip.Route,
+		Capacity: trip.Capacity,
+		Stops:    make([]TripStopLoad, len(trip.Stops)),
+	}
+
+	for i, stop := range trip.Stops {
+		profile.Stops[i].StopID = stop.ID
+	}
+
+	for _, ride := range trip.Rides {
+		endIndex := trip.CurrentIndex
+		if ride.IsFinished {
+			endIndex = ride.EndStopIndex
+			profile.Stops[endIndex].Alightings++
+		}
+
+		profile.Stops[ride.StartStopIndex].Boardings++
+		for i := ride.StartStopIndex; i < endIndex; i++ {
+			profile.Stops[i].Load++
+		}
+	}
+
+	for i, stop := range profile.Stops {
+		if stop.Load > profile.Stops[profile.PeakLoadStopIndex].Load {
+			profile.PeakLoadStopIndex = i
+		}
+	}
+
+	return profile
+}
+
+// Returns load profiles of the route in each direction and hour from stops visited by its trips,
+// with stops ordered along the route
+func GetRouteLoadProfiles(route string, trips []Trip) []RouteLoadProfile {
+	sums := make(map[stopLoadKey]*stopLoadSum)
+
+	for _, trip := range trips {
+		tripProfile := GetTripLoadProfile(&trip)
+
+		for i, stop := range tripProfile.Stops {
+			if !trip.IsVisited[i] {
+				continue
+			}
+
+			key := stopLoadKey{
+				direction: trip.Direction,
+				hour:      trip.Stops[i].Time / 3600,
+				stopID:    stop.StopID,
+			}
+			if i+1 < len(trip.Stops) {
+				key.nextStopID = trip.Stops[i+1].ID
+			}
+
+			sum, ok := sums[key]
+			if !ok {
+				sum = &stopLoadSum{
+					StopLoad:  StopLoad{StopID: key.stopID, NextStopID: key.nextStopID},
+					stopIndex: i,
+				}
+				sums[key] = sum
+			}
+
+			sum.stopIndex = min(sum.stopIndex, i)
+			sum.TripCount++
+			sum.Boardings += stop.Boardings
+			sum.Alightings += stop.Alightings
+			sum.MaxLoad = max(sum.MaxLoad, stop.Load)
+			sum.totalLoad += stop.Load
+			if trip.Capacity > 0 {
+				sum.limitedLoad += stop.Load
+				sum.totalCapacity += trip.Capacity
+			}
+		}
+	}
+
+	sumsByProfile := make(map[stopLoadKey][]*stopLoadSum)
+	for key, sum := range sums {
+		profileKey := stopLoadKey{direction: key.direction, hour: key.hour}
+		sumsByProfile[profileKey] = append(sumsByProfile[profileKey], sum)
+	}
+
+	profileKeys := slices.SortedFunc(maps.Keys(sumsByProfile), func(k1, k2 stopLoadKey) int {
+		return cmp.Or(cmp.Compare(k1.direction, k2.direction), cmp.Compare(k1.hour, k2.hour))
+	})
+
+	profiles := make([]RouteLoadProfile, 0, len(profileKeys))
+	for _, key := range profileKeys {
+		stopSums := sumsByProfile[key]
+		slices.SortFunc(stopSums, func(s1, s2 *stopLoadSum) int {
+			return cmp.Or(
+				cmp.Compare(s1.stopIndex, s2.stopIndex),
+				cmp.Compare(s1.StopID, s2.StopID),
+				cmp.Compare(s1.NextStopID, s2.NextStopID),
+			)
+		})
+
+		profile := RouteLoadProfile{
+			Route:     route,
+			Direction: key.direction,
+			Hour:      key.hour,
+			Stops:     make([]StopLoad, 0, len(stopSums)),
+		}
+
+		for i, sum := range stopSums {
+			sum.MeanLoad = float64(sum.totalLoad) / float64(sum.TripCount)
+			if sum.totalCapacity > 0 {
+				sum.LoadFactor = float64(sum.limitedLoad) / float64(sum.totalCapacity)
+			}
+
+			profile.Stops = append(profile.Stops, sum.StopLoad)
+			if sum.MeanLoad > profile.Stops[profile.PeakLoadStopIndex].MeanLoad {
+				profile.PeakLoadStopIndex = i
+			}
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles
+}
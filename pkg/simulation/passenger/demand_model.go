@@ -0,0 +1,224 @@
+package passenger
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+const (
+	DAY_TYPE_WEEKDAY = "weekday"
+	DAY_TYPE_WEEKEND = "weekend"
+
+	// Number of passengers per stop when the total passenger count isn't given
+	DEFAULT_PASSENGERS_PER_STOP = 500
+
+	// Maximum number of draws of a destination in a stop group different from the start stop
+	MAX_DESTINATION_DRAWS = 100
+)
+
+// Relative demand in each hour of the day, with morning and afternoon peaks on weekdays
+// and a single flat peak around midday on weekends
+var HOURLY_DEMAND_BY_DAY_TYPE = map[string][]float64{
+	DAY_TYPE_WEEKDAY: {
+		0.2, 0.1, 0.05, 0.05, 0.3, 1.5, 4.5, 7.5, 6.0, 4.0, 3.5, 3.8,
+		4.2, 4.8, 6.0, 7.0, 7.5, 6.5, 4.8, 3.5, 2.5, 2.0, 1.3, 0.7,
+	},
+	DAY_TYPE_WEEKEND: {
+		0.6, 0.4, 0.2, 0.1, 0.2, 0.5, 1.0, 1.8, 2.8, 3.8, 4.6, 5.0,
+		5.2, 5.2, 5.0, 4.8, 4.8, 4.8, 4.5, 3.8, 3.0, 2.4, 1.8, 1.2,
+	},
+}
+
+// Parameters of random passenger generation. Passengers start at stops drawn with
+// their weights, given for stop groups or single stops (taking precedence over groups),
+// stops without a weight have weight 1. Passengers with strategies other than random
+// travel to a stop group drawn the same way. Spawn hours are drawn with the hourly demand
+// curve, repeated after its end, or with the curve of the day type if none is given.
+type RandomDemandModel struct {
+	PassengerCount   uint               `json:"passengerCount,omitempty"`
+	DayType          string             `json:"dayType,omitempty"`
+	HourlyDemand     []float64          `json:"hourlyDemand,omitempty"`
+	StopGroupWeights map[string]float64 `json:"stopGroupWeights,omitempty"`
+	StopWeights      map[uint64]float64 `json:"stopWeights,omitempty"`
+	StrategyMix      map[string]float64 `json:"strategyMix,omitempty"`
+}
+
+var DEFAULT_RANDOM_DEMAND_MODEL = RandomDemandModel{
+	DayType:     DAY_TYPE_WEEKDAY,
+	StrategyMix: map[string]float64{string(travelplan.RANDOM): 1},
+}
+
+func (m *RandomDemandModel) Validate(currentCity *city.City) error {
+	if err := validateHourlyDemand(currentCity, m.DayType, m.HourlyDemand); err != nil {
+		return err
+	}
+
+	stopsByName := currentCity.GetStopsByName()
+	for name, weight := range m.StopGroupWeights {
+		if _, ok := stopsByName[name]; !ok {
+			return fmt.Errorf("stop group %q not found", name)
+		} else if weight < 0 {
+			return fmt.Errorf("weight of stop group %q can't be negative", name)
+		}
+	}
+
+	stopsByID := currentCity.GetStopsByID()
+	for stopID, weight := range m.StopWeights {
+		if _, ok := stopsByID[stopID]; !ok {
+			return fmt.Errorf("stop %d not found", stopID)
+		} else if weight < 0 {
+			return fmt.Errorf("weight of stop %d can't be negative", stopID)
+		}
+	}
+
+	stopWeights := make([]float64, 0, len(stopsByID))
+	for stopID, stop := range stopsByID {
+		stopWeights = append(stopWeights, m.getStopWeight(stopID, stop.GetGroupName()))
+	}
+
+	if err := validateWeights(stopWeights, "stop weights"); err != nil {
+		return err
+	}
+
+	return validateStrategyMix(m.StrategyMix, travelplan.RANDOM, travelplan.ASAP, travelplan.COMFORT, travelplan.SURE)
+}
+
+func validateHourlyDemand(currentCity *city.City, dayType string, hourlyDemand []float64) error {
+	if dayType != "" && HOURLY_DEMAND_BY_DAY_TYPE[dayType] == nil {
+		return fmt.Errorf("unknown day type %q", dayType)
+	}
+
+	if len(hourlyDemand) > 0 {
+		if err := validateWeights(hourlyDemand, "hourly demand"); err != nil {
+			return err
+		}
+	}
+
+	_, intervalWeights := getSpawnIntervals(getHourlyDemand(dayType, hourlyDemand), currentCity.GetTimeBounds())
+	if getWeightSum(intervalWeights) == 0 {
+		return fmt.Errorf("hourly demand must be positive in some hour of the schedule")
+	}
+
+	return nil
+}
+
+func validateStrategyMix(strategyMix map[string]float64, allowedStrategies ...travelplan.TravelPlanStrategy) error {
+	for name, weight := range strategyMix {
+		if !slices.Contains(allowedStrategies, travelplan.TravelPlanStrategy(strings.ToUpper(name))) {
+			return fmt.Errorf("unknown strategy %q", name)
+		} else if weight < 0 {
+			return fmt.Errorf("weight of strategy %q can't be negative", name)
+		}
+	}
+
+	if len(strategyMix) > 0 {
+		return validateWeights(slices.Collect(maps.Values(strategyMix)), "strategy mix")
+	}
+
+	return nil
+}
+
+func validateWeights(weights []float64, name string) error {
+	if slices.ContainsFunc(weights, func(weight float64) bool { return weight < 0 }) {
+		return fmt.Errorf("%s can't have negative weights", name)
+	} else if getWeightSum(weights) == 0 {
+		return fmt.Errorf("%s must have a positive weight", name)
+	}
+	return nil
+}
+
+func getWeightSum(weights []float64) (sum float64) {
+	for _, weight := range weights {
+		sum += weight
+	}
+	return
+}
+
+func getHourlyDemand(dayType string, hourlyDemand []float64) []float64 {
+	if len(hourlyDemand) > 0 {
+		return hourlyDemand
+	} else if dayType != "" {
+		return HOURLY_DEMAND_BY_DAY_TYPE[dayType]
+	}
+	return HOURLY_DEMAND_BY_DAY_TYPE[DAY_TYPE_WEEKDAY]
+}
+
+func (m *RandomDemandModel) getStopWeight(stopID uint64, groupName string) float64 {
+	if weight, ok := m.StopWeights[stopID]; ok {
+		return weight
+	} else if weight, ok := m.StopGroupWeights[groupName]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Returns strategies of the mix in sorted order with their weights, or of the default mix if none is given
+func getStrategyMix(strategyMix, defaultStrategyMix map[string]float64) ([]travelplan.TravelPlanStrategy, []float64) {
+	if len(strategyMix) == 0 {
+		strategyMix = defaultStrategyMix
+	}
+
+	strategies := make([]travelplan.TravelPlanStrategy, 0, len(strategyMix))
+	weights := make([]float64, 0, len(strategyMix))
+	for _, name := range slices.Sorted(maps.Keys(strategyMix)) {
+		strategies = append(strategies, travelplan.TravelPlanStrategy(strings.ToUpper(name)))
+		weights = append(weights, strategyMix[name])
+	}
+
+	return strategies, weights
+}
+
+// Part of an hour of the demand curve within the time bounds of the schedule
+type spawnInterval struct {
+	startTime, endTime uint
+}
+
+func getSpawnIntervals(hourlyDemand []float64, timeBounds city.TimeBounds) ([]spawnInterval, []float64) {
+	intervals := make([]spawnInterval, 0)
+	weights := make([]float64, 0)
+
+	for hour := timeBounds.StartTime / 3600; hour*3600 <= timeBounds.EndTime; hour++ {
+		interval := spawnInterval{
+			startTime: max(hour*3600, timeBounds.StartTime),
+			endTime:   min((hour+1)*3600, timeBounds.EndTime+1),
+		}
+
+		intervals = append(intervals, interval)
+		weights = append(weights, hourlyDemand[int(hour)%len(hourlyDemand)]*float64(interval.endTime-interval.startTime)/3600)
+	}
+
+	return intervals, weights
+}
+
+// Draws indices with probability proportional to their weights
+type weightedSampler struct {
+	cumulativeWeights []float64
+}
+
+func newWeightedSampler(weights []float64) weightedSampler {
+	cumulativeWeights := make([]float64, len(weights))
+	var sum float64
+	for i, weight := range weights {
+		sum += weight
+		cumulativeWeights[i] = sum
+	}
+	return weightedSampler{cumulativeWeights: cumulativeWeights}
+}
+
+func (s weightedSampler) sample(random *rand.Rand) int {
+	value := random.Float64() * s.cumulativeWeights[len(s.cumulativeWeights)-1]
+	// the first index with cumulative weight greater than the value, skipping zero weights
+	index, _ := slices.BinarySearchFunc(s.cumulativeWeights, value, func(weight, value float64) int {
+		if weight <= value {
+			return -1
+		}
+		return 1
+	})
+	return min(index, len(s.cumulativeWeights)-1)
+}
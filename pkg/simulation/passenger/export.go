@@ -3,6 +3,7 @@ package passenger
 import (
 	"fmt"
 	"io"
+	"strconv"
 )
 
 func (ps *PassengersStore) PassengersToCSVBuffer(writer io.Writer) error {
@@ -25,6 +26,29 @@ func (ps *PassengersStore) PassengersToCSVBuffer(writer io.Writer) error {
 	return nil
 }
 
+func (ps *PassengersStore) DeniedBoardingsToCSVBuffer(writer io.Writer) error {
+	writer.Write([]byte("passenger_id,tram_id,stop_id,time\n"))
+
+	for _, p := range ps.passengers {
+		for _, d := range p.DeniedBoardings {
+			_, err := fmt.Fprintf(
+				writer,
+				"%d,%d,%d,%d\n",
+				p.ID,
+				d.tramID,
+				d.stopID,
+				d.time,
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (ps *PassengersStore) PassengerTripsToCSVBuffer(writer io.Writer) error {
 	writer.Write([]byte("passenger_id,trip_sequence,tram_id,start_stop_id,get_on_time,end_stop_id,get_off_time\n"))
 
@@ -50,3 +74,32 @@ func (ps *PassengersStore) PassengerTripsToCSVBuffer(writer io.Writer) error {
 
 	return nil
 }
+
+func (ps *PassengersStore) ReplansToCSVBuffer(writer io.Writer) error {
+	writer.Write([]byte("passenger_id,stop_id,time,saved_time\n"))
+
+	for _, p := range ps.passengers {
+		for _, r := range p.Replans {
+			// saved time is empty if it's unknown
+			savedTime := ""
+			if r.isSavedTimeKnown {
+				savedTime = strconv.FormatUint(uint64(r.savedTime), 10)
+			}
+
+			_, err := fmt.Fprintf(
+				writer,
+				"%d,%d,%d,%s\n",
+				p.ID,
+				r.stopID,
+				r.time,
+				savedTime,
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"maps"
 	"math/rand/v2"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,33 +23,79 @@ type PassengerModelData struct {
 	strategy     travelplan.TravelPlanStrategy
 }
 
-func GenerateRandomPassengers(currentCity *city.City) (passengers []PassengerModelData) {
-	timeBounds := currentCity.GetTimeBounds()
+// Generates passengers at stops of the city following the demand model
+func GenerateRandomPassengers(currentCity *city.City, model *RandomDemandModel, random *rand.Rand) (passengers []PassengerModelData) {
 	stopsByID := currentCity.GetStopsByID()
+	stopIDs := slices.Sorted(maps.Keys(stopsByID))
+
+	stopWeights := make([]float64, len(stopIDs))
+	for i, stopID := range stopIDs {
+		stopWeights[i] = model.getStopWeight(stopID, stopsByID[stopID].GetGroupName())
+	}
+
+	intervals, intervalWeights := getSpawnIntervals(getHourlyDemand(model.DayType, model.HourlyDemand), currentCity.GetTimeBounds())
+	strategies, strategyWeights := getStrategyMix(model.StrategyMix, DEFAULT_RANDOM_DEMAND_MODEL.StrategyMix)
+
+	passengerCount := model.PassengerCount
+	if passengerCount == 0 {
+		passengerCount = uint(len(stopIDs)) * DEFAULT_PASSENGERS_PER_STOP
+	}
+
+	stopSampler := newWeightedSampler(stopWeights)
+	intervalSampler := newWeightedSampler(intervalWeights)
+	strategySampler := newWeightedSampler(strategyWeights)
 
 	// Start ID assignment from 1
-	passengerID := uint64(1)
-
-	for startStopID := range stopsByID {
-		for range 500 {
-			timeAfterStart := rand.IntN(int(timeBounds.EndTime - timeBounds.StartTime + 1))
-			spawnTime := timeBounds.StartTime + uint(timeAfterStart)
-
-			passengers = append(passengers, PassengerModelData{
-				ID:           passengerID,
-				startStopIDs: []uint64{startStopID},
-				endStopIDs:   nil,
-				spawnTime:    spawnTime,
-				strategy:     travelplan.RANDOM,
-			})
-
-			passengerID++
+	for passengerID := uint64(1); passengerID <= uint64(passengerCount); passengerID++ {
+		startStopID := stopIDs[stopSampler.sample(random)]
+		interval := intervals[intervalSampler.sample(random)]
+		strategy := strategies[strategySampler.sample(random)]
+
+		// Passengers going to a destination can start at any stop of the group
+		startStopIDs, endStopIDs := []uint64{startStopID}, []uint64(nil)
+		if strategy != travelplan.RANDOM {
+			endStopIDs = getRandomDestination(currentCity, stopIDs, stopSampler, startStopID, random)
+			if endStopIDs == nil {
+				strategy = travelplan.RANDOM
+			} else {
+				startStopIDs = currentCity.GetStopIDsInGroup(startStopID)
+			}
 		}
+
+		passengers = append(passengers, PassengerModelData{
+			ID:           passengerID,
+			startStopIDs: startStopIDs,
+			endStopIDs:   endStopIDs,
+			spawnTime:    interval.startTime + uint(random.IntN(int(interval.endTime-interval.startTime))),
+			strategy:     strategy,
+		})
 	}
 
 	return
 }
 
+// Returns IDs of stops of a group drawn with stop weights, other than the group of the start stop,
+// or nil if none was found
+func getRandomDestination(
+	currentCity *city.City,
+	stopIDs []uint64,
+	stopSampler weightedSampler,
+	startStopID uint64,
+	random *rand.Rand,
+) []uint64 {
+	stopsByID := currentCity.GetStopsByID()
+	startGroupName := stopsByID[startStopID].GetGroupName()
+
+	for range MAX_DESTINATION_DRAWS {
+		endStopID := stopIDs[stopSampler.sample(random)]
+		if stopsByID[endStopID].GetGroupName() != startGroupName {
+			return currentCity.GetStopIDsInGroup(endStopID)
+		}
+	}
+
+	return nil
+}
+
 func GeneratePassengersFromModel(currentCity *city.City, passengerModel []byte) (passengers []PassengerModelData, error error) {
 	records, err := readPassengerCSV(passengerModel)
 	if err != nil {
@@ -0,0 +1,199 @@
+package passenger
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+	"github.com/umahmood/haversine"
+)
+
+// Decay of the number of trips between stop groups with each kilometer of distance between them
+const DEFAULT_DISTANCE_DECAY = 0.25
+
+// Parameters of passenger generation with a gravity model. The number of trips between
+// two stop groups is proportional to the production of the start group, the attraction of
+// the end group and exp(-distanceDecay * distance in km). Without a production or attraction
+// table, the weight of a stop group is the number of routes serving its stops, otherwise
+// groups not listed in the table have weight 0. Spawn times follow the hourly demand curve
+// the same way as in random demand model.
+type GravityDemandModel struct {
+	PassengerCount uint               `json:"passengerCount,omitempty"`
+	DayType        string             `json:"dayType,omitempty"`
+	HourlyDemand   []float64          `json:"hourlyDemand,omitempty"`
+	Productions    map[string]float64 `json:"productions,omitempty"`
+	Attractions    map[string]float64 `json:"attractions,omitempty"`
+	DistanceDecay  *float64           `json:"distanceDecay,omitempty"`
+	StrategyMix    map[string]float64 `json:"strategyMix,omitempty"`
+}
+
+var DEFAULT_GRAVITY_STRATEGY_MIX = map[string]float64{string(travelplan.ASAP): 1}
+
+// Pair of stop groups with the number of trips between them given by the gravity model
+type gravityTrip struct {
+	startGroupName, endGroupName string
+}
+
+func (m *GravityDemandModel) Validate(currentCity *city.City) error {
+	if err := validateHourlyDemand(currentCity, m.DayType, m.HourlyDemand); err != nil {
+		return err
+	}
+
+	stopsByName := currentCity.GetStopsByName()
+	for tableName, table := range map[string]map[string]float64{"production": m.Productions, "attraction": m.Attractions} {
+		for name, weight := range table {
+			if _, ok := stopsByName[name]; !ok {
+				return fmt.Errorf("stop group %q not found", name)
+			} else if weight < 0 {
+				return fmt.Errorf("%s of stop group %q can't be negative", tableName, name)
+			}
+		}
+	}
+
+	if m.DistanceDecay != nil && *m.DistanceDecay < 0 {
+		return fmt.Errorf("distance decay can't be negative")
+	}
+
+	if err := validateStrategyMix(m.StrategyMix, travelplan.ASAP, travelplan.COMFORT, travelplan.SURE); err != nil {
+		return err
+	}
+
+	if _, tripWeights := m.getTrips(currentCity); getWeightSum(tripWeights) == 0 {
+		return fmt.Errorf("no trips between stop groups with positive production and attraction")
+	}
+
+	return nil
+}
+
+// Generates passengers travelling between stop groups drawn with the gravity model
+func GenerateGravityPassengers(currentCity *city.City, model *GravityDemandModel, random *rand.Rand) []PassengerModelData {
+	trips, tripWeights := model.getTrips(currentCity)
+	intervals, intervalWeights := getSpawnIntervals(getHourlyDemand(model.DayType, model.HourlyDemand), currentCity.GetTimeBounds())
+	strategies, strategyWeights := getStrategyMix(model.StrategyMix, DEFAULT_GRAVITY_STRATEGY_MIX)
+
+	passengerCount := model.PassengerCount
+	if passengerCount == 0 {
+		passengerCount = uint(len(currentCity.GetStopsByID())) * DEFAULT_PASSENGERS_PER_STOP
+	}
+
+	stopIDsByGroupName := make(map[string][]uint64)
+	for groupName, stops := range currentCity.GetStopsByName() {
+		stopIDsByGroupName[groupName] = slices.Sorted(maps.Keys(stops))
+	}
+
+	tripSampler := newWeightedSampler(tripWeights)
+	intervalSampler := newWeightedSampler(intervalWeights)
+	strategySampler := newWeightedSampler(strategyWeights)
+
+	passengers := make([]PassengerModelData, 0, passengerCount)
+
+	// Start ID assignment from 1
+	for passengerID := uint64(1); passengerID <= uint64(passengerCount); passengerID++ {
+		trip := trips[tripSampler.sample(random)]
+		interval := intervals[intervalSampler.sample(random)]
+
+		passengers = append(passengers, PassengerModelData{
+			ID:           passengerID,
+			startStopIDs: stopIDsByGroupName[trip.startGroupName],
+			endStopIDs:   stopIDsByGroupName[trip.endGroupName],
+			spawnTime:    interval.startTime + uint(random.IntN(int(interval.endTime-interval.startTime))),
+			strategy:     strategies[strategySampler.sample(random)],
+		})
+	}
+
+	return passengers
+}
+
+// Returns all pairs of different stop groups in sorted order with their weights
+func (m *GravityDemandModel) getTrips(currentCity *city.City) ([]gravityTrip, []float64) {
+	stopsByName := currentCity.GetStopsByName()
+	groupNames := slices.Sorted(maps.Keys(stopsByName))
+
+	routeCounts := getRouteCountsByGroupName(currentCity)
+	getWeight := func(table map[string]float64, groupName string) float64 {
+		if table == nil {
+			return float64(routeCounts[groupName])
+		}
+		return table[groupName]
+	}
+
+	coordinates := make(map[string]haversine.Coord, len(groupNames))
+	for _, groupName := range groupNames {
+		coordinates[groupName] = getGroupCoordinates(stopsByName[groupName])
+	}
+
+	distanceDecay := DEFAULT_DISTANCE_DECAY
+	if m.DistanceDecay != nil {
+		distanceDecay = *m.DistanceDecay
+	}
+
+	trips := make([]gravityTrip, 0, len(groupNames)*len(groupNames))
+	weights := make([]float64, 0, len(groupNames)*len(groupNames))
+
+	for _, startGroupName := range groupNames {
+		production := getWeight(m.Productions, startGroupName)
+
+		for _, endGroupName := range groupNames {
+			if startGroupName == endGroupName {
+				continue
+			}
+
+			_, kilometers := haversine.Distance(coordinates[startGroupName], coordinates[endGroupName])
+			weight := production * getWeight(m.Attractions, endGroupName) * math.Exp(-distanceDecay*kilometers)
+
+			trips = append(trips, gravityTrip{startGroupName: startGroupName, endGroupName: endGroupName})
+			weights = append(weights, weight)
+		}
+	}
+
+	return trips, weights
+}
+
+// Returns the number of distinct routes serving stops of each stop group
+func getRouteCountsByGroupName(currentCity *city.City) map[string]int {
+	stopsByID := currentCity.GetStopsByID()
+	routeSetByGroupName := make(map[string]*structs.Set[string])
+
+	for stopID, routes := range currentCity.GetRoutesByStopID() {
+		stop, ok := stopsByID[stopID]
+		if !ok {
+			continue
+		}
+
+		groupName := stop.GetGroupName()
+		if _, ok := routeSetByGroupName[groupName]; !ok {
+			set := structs.NewSet[string]()
+			routeSetByGroupName[groupName] = &set
+		}
+
+		for _, route := range routes {
+			routeSetByGroupName[groupName].Add(route.Name)
+		}
+	}
+
+	routeCounts := make(map[string]int, len(routeSetByGroupName))
+	for groupName, routeSet := range routeSetByGroupName {
+		routeCounts[groupName] = routeSet.Len()
+	}
+
+	return routeCounts
+}
+
+// Returns the mean of coordinates of stops of the group
+func getGroupCoordinates(stops map[uint64]*graph.GraphTramStop) (result haversine.Coord) {
+	for _, stopID := range slices.Sorted(maps.Keys(stops)) {
+		lat, lon := stops[stopID].GetCoordinates()
+		result.Lat += float64(lat)
+		result.Lon += float64(lon)
+	}
+
+	result.Lat /= float64(len(stops))
+	result.Lon /= float64(len(stops))
+	return
+}
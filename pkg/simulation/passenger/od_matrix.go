@@ -0,0 +1,166 @@
+package passenger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+// Columns of OD matrix CSV before the strategy mix columns
+const OD_MATRIX_FIXED_COLUMNS = 5
+
+// Number of passengers travelling between two stop groups in the time bucket,
+// with strategies drawn from the mix weighted by the strategy columns
+type odMatrixEntry struct {
+	startStopIDs, endStopIDs []uint64
+	startTime, endTime       uint
+	count                    uint
+	strategyWeights          []float64
+}
+
+// Generates passengers from OD matrix CSV with the header
+// `start,end,start_time,end_time,count` followed by columns named after travel plan strategies.
+// Spawn times are sampled uniformly inside each time bucket, strategies with the weights
+// given in the strategy columns. IDs of passengers start from firstID.
+func GeneratePassengersFromODMatrix(
+	currentCity *city.City,
+	odMatrix []byte,
+	firstID uint64,
+	random *rand.Rand,
+) ([]PassengerModelData, error) {
+	records, err := csv.NewReader(bytes.NewReader(odMatrix)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading OD matrix csv: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("OD matrix csv is empty")
+	}
+
+	strategies, err := getODMatrixStrategies(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	stopsByName := currentCity.GetStopsByName()
+	entries := make([]odMatrixEntry, 0, len(records)-1)
+
+	for i, row := range records[1:] {
+		entry, err := getODMatrixEntryFromRow(row, len(strategies), stopsByName)
+		if err != nil {
+			return nil, fmt.Errorf("OD matrix row %d: %w", i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	passengers := make([]PassengerModelData, 0)
+	passengerID := firstID
+
+	for _, entry := range entries {
+		strategySampler := newWeightedSampler(entry.strategyWeights)
+		for range entry.count {
+			passengers = append(passengers, PassengerModelData{
+				ID:           passengerID,
+				startStopIDs: entry.startStopIDs,
+				endStopIDs:   entry.endStopIDs,
+				spawnTime:    entry.startTime + uint(random.IntN(int(entry.endTime-entry.startTime))),
+				strategy:     strategies[strategySampler.sample(random)],
+			})
+
+			passengerID++
+		}
+	}
+
+	return passengers, nil
+}
+
+func getODMatrixStrategies(header []string) ([]travelplan.TravelPlanStrategy, error) {
+	if len(header) <= OD_MATRIX_FIXED_COLUMNS {
+		return nil, fmt.Errorf("invalid OD matrix header, expected at least one strategy column after %d columns", OD_MATRIX_FIXED_COLUMNS)
+	}
+
+	strategies := make([]travelplan.TravelPlanStrategy, 0, len(header)-OD_MATRIX_FIXED_COLUMNS)
+	for _, column := range header[OD_MATRIX_FIXED_COLUMNS:] {
+		strategy := travelplan.TravelPlanStrategy(strings.ToUpper(strings.TrimSpace(column)))
+		switch strategy {
+		case travelplan.RANDOM, travelplan.ASAP, travelplan.COMFORT, travelplan.SURE:
+			strategies = append(strategies, strategy)
+		default:
+			return nil, fmt.Errorf("invalid OD matrix header, unknown strategy %q", column)
+		}
+	}
+
+	return strategies, nil
+}
+
+func getODMatrixEntryFromRow(
+	row []string,
+	strategyCount int,
+	stopsByName map[string]map[uint64]*graph.GraphTramStop,
+) (entry odMatrixEntry, err error) {
+	if len(row) != OD_MATRIX_FIXED_COLUMNS+strategyCount {
+		return entry, fmt.Errorf("expected %d columns, got %d", OD_MATRIX_FIXED_COLUMNS+strategyCount, len(row))
+	}
+
+	if entry.startStopIDs, err = getStopIDsFromGroupName(stopsByName, strings.TrimSpace(row[0])); err != nil {
+		return
+	}
+
+	if entry.endStopIDs, err = getStopIDsFromGroupName(stopsByName, strings.TrimSpace(row[1])); err != nil {
+		return
+	}
+
+	if entry.startTime, err = parseODMatrixTime(row[2]); err != nil {
+		return
+	}
+
+	if entry.endTime, err = parseODMatrixTime(row[3]); err != nil {
+		return
+	}
+
+	if entry.endTime <= entry.startTime {
+		return entry, fmt.Errorf("end time of the time bucket must be after its start time")
+	}
+
+	count, err := strconv.ParseUint(strings.TrimSpace(row[4]), 10, 32)
+	if err != nil {
+		return entry, fmt.Errorf("invalid count %q", row[4])
+	}
+	entry.count = uint(count)
+
+	var weightSum float64
+	entry.strategyWeights = make([]float64, strategyCount)
+	for i, value := range row[OD_MATRIX_FIXED_COLUMNS:] {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return entry, fmt.Errorf("invalid strategy weight %q", value)
+		}
+		entry.strategyWeights[i] = weight
+		weightSum += weight
+	}
+
+	if weightSum == 0 && entry.count > 0 {
+		return entry, fmt.Errorf("strategy weights can't all be zero")
+	}
+
+	return entry, nil
+}
+
+// Parses time of a time bucket in HH:MM:SS format, hours may exceed 23 for times after midnight
+func parseODMatrixTime(value string) (uint, error) {
+	value = strings.TrimSpace(value)
+
+	var hours, minutes, seconds uint
+	if _, err := fmt.Sscanf(value, "%d:%d:%d", &hours, &minutes, &seconds); err != nil || minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM:SS)", value)
+	}
+
+	return hours*3600 + minutes*60 + seconds, nil
+}
@@ -1,11 +1,11 @@
 package passenger
 
 import (
+	"cmp"
+	"slices"
 	"sync"
-)
 
-const (
-	MAX_PASSENGERS_CHANGE_RATE = 10
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
 )
 
 type passengerStop struct {
@@ -32,25 +32,57 @@ func (ps *passengerStop) despawnPassenger(passenger *Passenger) {
 	delete(ps.passengers, passenger.ID)
 }
 
-func (ps *passengerStop) loadPassengersToTram(tramID, time uint) []*Passenger {
+// Returns boarded passengers and true if there are more passengers waiting for the tram
+func (ps *passengerStop) loadPassengersToTram(tramID, time uint, stopIndex, maxCount int) ([]*Passenger, bool) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
-	boardingPassengers := make([]*Passenger, 0, MAX_PASSENGERS_CHANGE_RATE)
+	boardingPassengers := make([]*Passenger, 0)
 	for _, p := range ps.passengers {
 		if p.TravelPlan.ContainsConnection(ps.stopID, tramID) {
 			boardingPassengers = append(boardingPassengers, p)
-			p.saveNewTrip(tramID, time, ps.stopID, p.TravelPlan.GetConnectionDestination(tramID))
-		}
-
-		if len(boardingPassengers) >= MAX_PASSENGERS_CHANGE_RATE {
-			break
 		}
 	}
 
+	waitingCount := len(boardingPassengers)
+
+	// Passengers are boarding in the order of their IDs to keep the results reproducible
+	boardingPassengers = FirstPassengersByID(boardingPassengers, maxCount)
+
 	for _, p := range boardingPassengers {
+		p.saveNewTrip(tramID, time, ps.stopID, p.TravelPlan.GetConnectionDestination(tramID), stopIndex)
 		delete(ps.passengers, p.ID)
 	}
 
-	return boardingPassengers
+	return boardingPassengers, waitingCount > len(boardingPassengers)
+}
+
+// Records denied boarding of passengers who couldn't get on the full tram
+// and makes them wait for another connection from the stop, or plan their
+// travel again if the travel plan has no other connection they can make
+func (ps *passengerStop) leavePassengersBehind(c *city.City, tramID, time uint) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	leftBehindPassengers := make([]*Passenger, 0)
+	for _, p := range ps.passengers {
+		if p.TravelPlan.ContainsConnection(ps.stopID, tramID) {
+			leftBehindPassengers = append(leftBehindPassengers, p)
+		}
+	}
+
+	for _, p := range FirstPassengersByID(leftBehindPassengers, len(leftBehindPassengers)) {
+		p.saveDeniedBoarding(tramID, time, ps.stopID)
+		if !p.TravelPlan.ReplaceMissedConnection(c, ps.stopID, tramID, time) {
+			p.replanFromStop(c, ps.stopID, time)
+		}
+	}
+}
+
+func FirstPassengersByID(passengers []*Passenger, count int) []*Passenger {
+	slices.SortFunc(passengers, func(p1, p2 *Passenger) int {
+		return cmp.Compare(p1.ID, p2.ID)
+	})
+
+	return passengers[:min(len(passengers), count)]
 }
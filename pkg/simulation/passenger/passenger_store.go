@@ -1,7 +1,10 @@
 package passenger
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
@@ -19,6 +22,7 @@ type passengerSpawn struct {
 }
 
 type PassengersStore struct {
+	city              *city.City
 	passengers        []Passenger
 	passengerStops    map[uint64]*passengerStop
 	passengersToSpawn map[uint][]passengerSpawn
@@ -29,6 +33,7 @@ func NewPassengersStore(c *city.City, passengers []Passenger) *PassengersStore {
 	stopsByID := c.GetStopsByID()
 
 	store := &PassengersStore{
+		city:              c,
 		passengers:        passengers,
 		passengerStops:    make(map[uint64]*passengerStop, len(stopsByID)),
 		passengersToSpawn: make(map[uint][]passengerSpawn),
@@ -51,10 +56,57 @@ func NewPassengersStore(c *city.City, passengers []Passenger) *PassengersStore {
 	return store
 }
 
+// Returns passenger with the given ID or nil if it doesn't exist
+func (ps *PassengersStore) GetPassenger(passengerID uint64) *Passenger {
+	i, ok := slices.BinarySearchFunc(ps.passengers, passengerID, func(p Passenger, id uint64) int {
+		return cmp.Compare(p.ID, id)
+	})
+
+	if !ok {
+		return nil
+	}
+
+	return &ps.passengers[i]
+}
+
+// Ride of a passenger on a tram between stops with the given indexes in its trip,
+// not finished while the passenger is still in the tram
+type Ride struct {
+	StartStopIndex int
+	EndStopIndex   int
+	IsFinished     bool
+}
+
+// Returns rides of passengers on trams, grouped by tram ID
+func (ps *PassengersStore) GetRidesByTramID() map[uint][]Ride {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ridesByTramID := make(map[uint][]Ride)
+	for _, p := range ps.passengers {
+		for _, t := range p.TakenTrips {
+			ridesByTramID[t.tramID] = append(ridesByTramID[t.tramID], Ride{
+				StartStopIndex: t.startStopIndex,
+				EndStopIndex:   t.endStopIndex,
+				IsFinished:     t.getOffTime != 0,
+			})
+		}
+	}
+	return ridesByTramID
+}
+
 func (ps *PassengersStore) GetPassengerCountAtStop(stopID uint64) uint {
 	return ps.passengerStops[stopID].GetPassengerCount()
 }
 
+func (ps *PassengersStore) GetPassengerCounts() map[uint64]uint {
+	result := make(map[uint64]uint, len(ps.passengerStops))
+	for stopID, stop := range ps.passengerStops {
+		result[stopID] = stop.GetPassengerCount()
+	}
+	return result
+}
+
 func getStopIDsFromGroupName(stopsByName map[string]map[uint64]*graph.GraphTramStop, stopName string) ([]uint64, error) {
 	if stopName == "" {
 		return nil, fmt.Errorf("empty stop group name")
@@ -65,12 +117,7 @@ func getStopIDsFromGroupName(stopsByName map[string]map[uint64]*graph.GraphTramS
 		return nil, fmt.Errorf("stop group %q not found", stopName)
 	}
 
-	ids := make([]uint64, 0, len(group))
-	for id := range group {
-		ids = append(ids, id)
-	}
-
-	return ids, nil
+	return slices.Sorted(maps.Keys(group)), nil
 }
 
 func (ps *PassengersStore) ResetPassengers() {
@@ -111,19 +158,33 @@ func (ps *PassengersStore) DespawnPassengersAtTime(time uint) {
 	}
 }
 
-func (ps *PassengersStore) LoadPassengers(stopID uint64, tramID, time uint) []*Passenger {
+// Loads at most maxCount passengers waiting for the tram at the stop.
+// Returns boarded passengers and true if there are more passengers waiting for the tram.
+// Boards passengers waiting at the stop with the given index in the trip of the tram
+func (ps *PassengersStore) LoadPassengers(stopID uint64, stopIndex int, tramID, time uint, maxCount int) ([]*Passenger, bool) {
+	// Taken trips of boarding passengers are read by GetRidesByTramID
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	passengerStop := ps.passengerStops[stopID]
-	return passengerStop.loadPassengersToTram(tramID, time)
+	return passengerStop.loadPassengersToTram(tramID, time, stopIndex, maxCount)
+}
+
+// Passengers waiting for the tram at the stop, who couldn't board it, try to take another connection
+func (ps *PassengersStore) LeavePassengersBehind(stopID uint64, tramID, time uint) {
+	ps.passengerStops[stopID].leavePassengersBehind(ps.city, tramID, time)
 }
 
-func (ps *PassengersStore) UnloadPassengers(passengers []*Passenger, stopID uint64, time uint) {
+func (ps *PassengersStore) UnloadPassengers(passengers []*Passenger, stopID uint64, stopIndex int, time uint) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
 	for _, p := range passengers {
-		p.saveGetOffTime(time)
+		p.saveGetOff(stopID, stopIndex, time)
 
-		if p.TravelPlan.IsEndStopReached(stopID) {
+		// Passengers which had to leave the tram outside of their travel plan,
+		// e.g. because their stop was skipped, end their travel there
+		if p.TravelPlan.IsEndStopReached(stopID) || !p.TravelPlan.ContainsStop(stopID) {
 			continue
 		}
 
@@ -1,32 +1,48 @@
 package passenger
 
 import (
+	"cmp"
 	"log"
 	"runtime"
+	"slices"
+	"time"
 
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
 )
 
+// Stop indexes are the indexes of the stops in the trip, the same stop may be visited
+// more than once, e.g. by trips of loop routes
 type takenTrip struct {
-	tramID                 uint
-	tripSequence           int
-	startStopID, endStopID uint64
-	getOnTime, getOffTime  uint
+	tramID                       uint
+	tripSequence                 int
+	startStopID, endStopID       uint64
+	startStopIndex, endStopIndex int
+	getOnTime, getOffTime        uint
+}
+
+type deniedBoarding struct {
+	tramID uint
+	stopID uint64
+	time   uint
 }
 
 type travelPlanWorkerInput struct {
 	currentCity *city.City
 	data        PassengerModelData
+	choiceModel *travelplan.ChoiceModel
+	seed        uint64
 }
 
 type Passenger struct {
-	ID         uint64
-	strategy   travelplan.TravelPlanStrategy
-	spawnTime  uint
-	TravelPlan travelplan.TravelPlan
-	TakenTrips []takenTrip
+	ID              uint64
+	strategy        travelplan.TravelPlanStrategy
+	spawnTime       uint
+	TravelPlan      travelplan.TravelPlan
+	TakenTrips      []takenTrip
+	DeniedBoardings []deniedBoarding
+	Replans         []replan
 }
 
 func passengerWorker(state *structs.WorkerState[travelPlanWorkerInput, Passenger]) {
@@ -37,6 +53,8 @@ func passengerWorker(state *structs.WorkerState[travelPlanWorkerInput, Passenger
 			input.data.startStopIDs,
 			input.data.endStopIDs,
 			input.data.spawnTime,
+			input.choiceModel,
+			structs.NewRandom(input.seed, structs.TravelPlanRandomStream, input.data.ID),
 		)
 
 		if !ok {
@@ -59,8 +77,11 @@ func passengerWorker(state *structs.WorkerState[travelPlanWorkerInput, Passenger
 func PassengersFromModelData(
 	currentCity *city.City,
 	data []PassengerModelData,
+	choiceModel *travelplan.ChoiceModel,
 	workerNumber uint,
+	seed uint64,
 ) (passengers []Passenger) {
+	startTime := time.Now()
 	workerState := structs.NewWorkerState[travelPlanWorkerInput, Passenger](len(data))
 
 	if workerNumber == 0 {
@@ -78,6 +99,8 @@ func PassengersFromModelData(
 		workerState.InputChannel <- travelPlanWorkerInput{
 			currentCity: currentCity,
 			data:        data,
+			choiceModel: choiceModel,
+			seed:        seed,
 		}
 	}
 
@@ -89,25 +112,44 @@ func PassengersFromModelData(
 
 	workerState.Stop()
 
+	// Travel plans are created concurrently, restore the order of passengers
+	slices.SortFunc(passengers, func(p1, p2 Passenger) int {
+		return cmp.Compare(p1.ID, p2.ID)
+	})
+
+	log.Default().Printf("Travel plans of %d out of %d passengers created in %s", len(passengers), len(data), time.Since(startTime))
+
 	return
 }
 
-func (p *Passenger) saveNewTrip(tramID, time uint, startStopID, endStopID uint64) {
+func (p *Passenger) saveNewTrip(tramID, time uint, startStopID, endStopID uint64, startStopIndex int) {
 	tripSequence := len(p.TakenTrips) + 1
 	p.TakenTrips = append(p.TakenTrips, takenTrip{
-		tramID:       tramID,
-		tripSequence: tripSequence,
-		getOnTime:    time,
-		startStopID:  startStopID,
-		endStopID:    endStopID,
+		tramID:         tramID,
+		tripSequence:   tripSequence,
+		getOnTime:      time,
+		startStopID:    startStopID,
+		endStopID:      endStopID,
+		startStopIndex: startStopIndex,
+	})
+}
+
+func (p *Passenger) saveDeniedBoarding(tramID, time uint, stopID uint64) {
+	p.DeniedBoardings = append(p.DeniedBoardings, deniedBoarding{
+		tramID: tramID,
+		stopID: stopID,
+		time:   time,
 	})
 }
 
-func (p *Passenger) saveGetOffTime(time uint) {
+// Passengers may get off before the planned stop, e.g. when it's skipped by the tram
+func (p *Passenger) saveGetOff(stopID uint64, stopIndex int, time uint) {
 	lastTripIdx := len(p.TakenTrips) - 1
 	if lastTripIdx < 0 {
 		panic("Passenger have not taken any trips yet")
 	}
 
+	p.TakenTrips[lastTripIdx].endStopID = stopID
+	p.TakenTrips[lastTripIdx].endStopIndex = stopIndex
 	p.TakenTrips[lastTripIdx].getOffTime = time
 }
@@ -0,0 +1,151 @@
+package passenger
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+// Decides when waiting passengers re-plan their travel, because the trams they wait for
+// are stopped or late. Passengers of RANDOM strategy don't re-plan their travel.
+type ReplanningModel struct {
+	// delay in seconds of the awaited trams, from which passengers look for a faster journey
+	DelayThreshold uint `json:"delayThreshold"`
+	// interval in seconds between checks of the awaited trams
+	CheckInterval uint `json:"checkInterval"`
+}
+
+var DEFAULT_REPLANNING_MODEL = ReplanningModel{
+	DelayThreshold: 5 * 60,
+	CheckInterval:  60,
+}
+
+func (m *ReplanningModel) Validate() error {
+	if m.CheckInterval == 0 {
+		return fmt.Errorf("check interval must be positive")
+	}
+
+	return nil
+}
+
+// Checks if the awaited trams should be checked at the time
+func (m *ReplanningModel) IsCheckTime(time uint) bool {
+	return time%m.CheckInterval == 0
+}
+
+// Change of the travel plan of a waiting passenger. Saved time is the difference between
+// estimated arrivals with the previous and the new travel plan, unknown if none
+// of the awaited trams was going to depart.
+type replan struct {
+	stopID           uint64
+	time             uint
+	savedTime        uint
+	isSavedTimeKnown bool
+}
+
+// Passengers waiting for trams, which are stopped or delayed by at least the threshold,
+// re-plan their travel from the stop using live estimates of trips. A new travel plan
+// is taken if it's estimated to arrive earlier or none of the awaited trams is going to depart.
+func (ps *PassengersStore) ReplanPassengers(model *ReplanningModel, liveEstimates travelplan.LiveEstimates, currentTime uint) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	var replanCount, unknownSavedTimeCount int
+	var savedTime uint
+
+	for _, stop := range ps.passengerStops {
+		for _, r := range stop.replanPassengers(ps.city, model, liveEstimates, currentTime) {
+			replanCount++
+			savedTime += r.savedTime
+			if !r.isSavedTimeKnown {
+				unknownSavedTimeCount++
+			}
+		}
+	}
+
+	if replanCount > 0 {
+		log.Default().Printf(
+			"%d passengers re-planned their travel at %d, saving %s of estimated travel time (unknown for %d passengers with none of the awaited trams departing)",
+			replanCount,
+			currentTime,
+			time.Duration(savedTime)*time.Second,
+			unknownSavedTimeCount,
+		)
+	}
+}
+
+func (ps *passengerStop) replanPassengers(
+	c *city.City,
+	model *ReplanningModel,
+	liveEstimates travelplan.LiveEstimates,
+	currentTime uint,
+) []replan {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	replans := make([]replan, 0)
+
+	for _, p := range ps.passengers {
+		if r, ok := p.replan(c, model, liveEstimates, ps.stopID, currentTime); ok {
+			replans = append(replans, r)
+		}
+	}
+
+	return replans
+}
+
+func (p *Passenger) replan(
+	c *city.City,
+	model *ReplanningModel,
+	liveEstimates travelplan.LiveEstimates,
+	stopID uint64,
+	currentTime uint,
+) (replan, bool) {
+	if p.strategy == travelplan.RANDOM {
+		return replan{}, false
+	}
+
+	delay, isDeparting := p.TravelPlan.GetConnectionDelay(c, stopID, liveEstimates)
+	if isDeparting && delay < model.DelayThreshold {
+		return replan{}, false
+	}
+
+	travelPlan, ok := travelplan.GetLiveTravelPlan(c, p.strategy, stopID, p.TravelPlan.GetEndStopIDs(), currentTime, liveEstimates)
+	if !ok {
+		return replan{}, false
+	}
+
+	r := replan{stopID: stopID, time: currentTime}
+
+	if isDeparting && p.TravelPlan.GetArrivalTime() > 0 {
+		newDelay, _ := travelPlan.GetConnectionDelay(c, stopID, liveEstimates)
+		estimatedArrival := p.TravelPlan.GetArrivalTime() + delay
+		newEstimatedArrival := travelPlan.GetArrivalTime() + newDelay
+
+		if newEstimatedArrival >= estimatedArrival {
+			return replan{}, false
+		}
+
+		r.savedTime, r.isSavedTimeKnown = estimatedArrival-newEstimatedArrival, true
+	}
+
+	p.TravelPlan = travelPlan
+	p.Replans = append(p.Replans, r)
+
+	return r, true
+}
+
+// Plans travel from the stop again with scheduled times of trips, after the passenger
+// missed a connection which couldn't be replaced in the travel plan
+func (p *Passenger) replanFromStop(c *city.City, stopID uint64, currentTime uint) {
+	if p.strategy == travelplan.RANDOM {
+		return
+	}
+
+	if travelPlan, ok := travelplan.GetLiveTravelPlan(c, p.strategy, stopID, p.TravelPlan.GetEndStopIDs(), currentTime, nil); ok {
+		p.TravelPlan = travelPlan
+	}
+}
@@ -0,0 +1,235 @@
+package passenger
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+type TakenTripSnapshot struct {
+	TramID         uint   `json:"tramID"`
+	TripSequence   int    `json:"tripSequence"`
+	StartStopID    uint64 `json:"startStopID"`
+	EndStopID      uint64 `json:"endStopID"`
+	StartStopIndex int    `json:"startStopIndex"`
+	EndStopIndex   int    `json:"endStopIndex"`
+	GetOnTime      uint   `json:"getOnTime"`
+	GetOffTime     uint   `json:"getOffTime"`
+}
+
+type DeniedBoardingSnapshot struct {
+	TramID uint   `json:"tramID"`
+	StopID uint64 `json:"stopID"`
+	Time   uint   `json:"time"`
+}
+
+type ReplanSnapshot struct {
+	StopID           uint64 `json:"stopID"`
+	Time             uint   `json:"time"`
+	SavedTime        uint   `json:"savedTime"`
+	IsSavedTimeKnown bool   `json:"isSavedTimeKnown"`
+}
+
+type PassengerSnapshot struct {
+	ID         uint64                        `json:"id"`
+	Strategy   travelplan.TravelPlanStrategy `json:"strategy"`
+	SpawnTime  uint                          `json:"spawnTime"`
+	TravelPlan travelplan.TravelPlanSnapshot `json:"travelPlan"`
+	TakenTrips []TakenTripSnapshot           `json:"takenTrips"`
+	// Optional, missing in snapshots of simulations without denied boardings
+	DeniedBoardings []DeniedBoardingSnapshot `json:"deniedBoardings,omitempty"`
+	// Optional, missing in snapshots of simulations without re-planned travel
+	Replans []ReplanSnapshot `json:"replans,omitempty"`
+}
+
+type PassengerSpawnSnapshot struct {
+	PassengerID uint64 `json:"passengerID"`
+	StopID      uint64 `json:"stopID"`
+}
+
+type PassengersStoreSnapshot struct {
+	Passengers        []PassengerSnapshot               `json:"passengers"`
+	PassengersAtStops map[uint64][]uint64               `json:"passengersAtStops"`
+	PassengersToSpawn map[uint][]PassengerSpawnSnapshot `json:"passengersToSpawn"`
+}
+
+func (p *Passenger) getSnapshot() PassengerSnapshot {
+	takenTrips := make([]TakenTripSnapshot, 0, len(p.TakenTrips))
+	for _, t := range p.TakenTrips {
+		takenTrips = append(takenTrips, TakenTripSnapshot{
+			TramID:         t.tramID,
+			TripSequence:   t.tripSequence,
+			StartStopID:    t.startStopID,
+			EndStopID:      t.endStopID,
+			StartStopIndex: t.startStopIndex,
+			EndStopIndex:   t.endStopIndex,
+			GetOnTime:      t.getOnTime,
+			GetOffTime:     t.getOffTime,
+		})
+	}
+
+	var deniedBoardings []DeniedBoardingSnapshot
+	for _, d := range p.DeniedBoardings {
+		deniedBoardings = append(deniedBoardings, DeniedBoardingSnapshot{
+			TramID: d.tramID,
+			StopID: d.stopID,
+			Time:   d.time,
+		})
+	}
+
+	var replans []ReplanSnapshot
+	for _, r := range p.Replans {
+		replans = append(replans, ReplanSnapshot{
+			StopID:           r.stopID,
+			Time:             r.time,
+			SavedTime:        r.savedTime,
+			IsSavedTimeKnown: r.isSavedTimeKnown,
+		})
+	}
+
+	return PassengerSnapshot{
+		ID:              p.ID,
+		Strategy:        p.strategy,
+		SpawnTime:       p.spawnTime,
+		TravelPlan:      p.TravelPlan.GetSnapshot(),
+		TakenTrips:      takenTrips,
+		DeniedBoardings: deniedBoardings,
+		Replans:         replans,
+	}
+}
+
+func passengerFromSnapshot(snapshot PassengerSnapshot) Passenger {
+	takenTrips := make([]takenTrip, 0, len(snapshot.TakenTrips))
+	for _, t := range snapshot.TakenTrips {
+		takenTrips = append(takenTrips, takenTrip{
+			tramID:         t.TramID,
+			tripSequence:   t.TripSequence,
+			startStopID:    t.StartStopID,
+			endStopID:      t.EndStopID,
+			startStopIndex: t.StartStopIndex,
+			endStopIndex:   t.EndStopIndex,
+			getOnTime:      t.GetOnTime,
+			getOffTime:     t.GetOffTime,
+		})
+	}
+
+	var deniedBoardings []deniedBoarding
+	for _, d := range snapshot.DeniedBoardings {
+		deniedBoardings = append(deniedBoardings, deniedBoarding{
+			tramID: d.TramID,
+			stopID: d.StopID,
+			time:   d.Time,
+		})
+	}
+
+	var replans []replan
+	for _, r := range snapshot.Replans {
+		replans = append(replans, replan{
+			stopID:           r.StopID,
+			time:             r.Time,
+			savedTime:        r.SavedTime,
+			isSavedTimeKnown: r.IsSavedTimeKnown,
+		})
+	}
+
+	return Passenger{
+		ID:              snapshot.ID,
+		strategy:        snapshot.Strategy,
+		spawnTime:       snapshot.SpawnTime,
+		TravelPlan:      travelplan.TravelPlanFromSnapshot(snapshot.TravelPlan),
+		TakenTrips:      takenTrips,
+		DeniedBoardings: deniedBoardings,
+		Replans:         replans,
+	}
+}
+
+func (ps *PassengersStore) GetSnapshot() PassengersStoreSnapshot {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	snapshot := PassengersStoreSnapshot{
+		Passengers:        make([]PassengerSnapshot, 0, len(ps.passengers)),
+		PassengersAtStops: make(map[uint64][]uint64),
+		PassengersToSpawn: make(map[uint][]PassengerSpawnSnapshot, len(ps.passengersToSpawn)),
+	}
+
+	for i := range ps.passengers {
+		snapshot.Passengers = append(snapshot.Passengers, ps.passengers[i].getSnapshot())
+	}
+
+	for stopID, stop := range ps.passengerStops {
+		stop.mu.Lock()
+		if len(stop.passengers) > 0 {
+			snapshot.PassengersAtStops[stopID] = slices.Sorted(maps.Keys(stop.passengers))
+		}
+		stop.mu.Unlock()
+	}
+
+	for time, spawnList := range ps.passengersToSpawn {
+		spawns := make([]PassengerSpawnSnapshot, 0, len(spawnList))
+		for _, entry := range spawnList {
+			spawns = append(spawns, PassengerSpawnSnapshot{
+				PassengerID: entry.passenger.ID,
+				StopID:      entry.stopID,
+			})
+		}
+
+		slices.SortFunc(spawns, func(s1, s2 PassengerSpawnSnapshot) int {
+			return cmp.Or(cmp.Compare(s1.PassengerID, s2.PassengerID), cmp.Compare(s1.StopID, s2.StopID))
+		})
+
+		snapshot.PassengersToSpawn[time] = spawns
+	}
+
+	return snapshot
+}
+
+func PassengersStoreFromSnapshot(c *city.City, snapshot PassengersStoreSnapshot) (*PassengersStore, error) {
+	passengers := make([]Passenger, 0, len(snapshot.Passengers))
+	for _, p := range snapshot.Passengers {
+		passengers = append(passengers, passengerFromSnapshot(p))
+	}
+
+	slices.SortFunc(passengers, func(p1, p2 Passenger) int {
+		return cmp.Compare(p1.ID, p2.ID)
+	})
+
+	store := NewPassengersStore(c, passengers)
+	store.passengersToSpawn = make(map[uint][]passengerSpawn, len(snapshot.PassengersToSpawn))
+
+	for time, spawns := range snapshot.PassengersToSpawn {
+		for _, spawn := range spawns {
+			p := store.GetPassenger(spawn.PassengerID)
+			if p == nil {
+				return nil, fmt.Errorf("passenger %d not found", spawn.PassengerID)
+			}
+
+			store.passengersToSpawn[time] = append(store.passengersToSpawn[time], passengerSpawn{
+				passenger: p,
+				stopID:    spawn.StopID,
+			})
+		}
+	}
+
+	for stopID, passengerIDs := range snapshot.PassengersAtStops {
+		stop, ok := store.passengerStops[stopID]
+		if !ok {
+			return nil, fmt.Errorf("stop %d not found", stopID)
+		}
+
+		for _, passengerID := range passengerIDs {
+			p := store.GetPassenger(passengerID)
+			if p == nil {
+				return nil, fmt.Errorf("passenger %d not found", passengerID)
+			}
+
+			stop.addPassengerToStop(p)
+		}
+	}
+
+	return store, nil
+}
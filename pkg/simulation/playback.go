@@ -0,0 +1,49 @@
+package simulation
+
+import (
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/timeline"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+)
+
+func (s *Simulation) resetTimeline() {
+	positions := make([]tram.TramPositionChange, 0)
+	for _, tram := range s.trams {
+		if position, ok := tram.GetPositionChange(s.time); ok {
+			positions = append(positions, position)
+		}
+	}
+
+	s.timeline.Reset(s.time, positions, s.passengersStore.GetPassengerCounts())
+}
+
+// Enables or disables recording the timeline used for playback, which takes memory
+// growing with the simulated time. Recording starts again from the current state.
+func (s *Simulation) SetTimelineRecording(isRecording bool) {
+	s.timeline.SetRecording(isRecording)
+	s.resetTimeline()
+}
+
+// Returns state of the simulation recorded at the given time
+func (s *Simulation) GetFrame(time uint) timeline.Frame {
+	return s.timeline.GetFrame(time)
+}
+
+// Moves playback to the given time and returns state of the simulation recorded at that time.
+// Playback doesn't affect the simulation itself.
+func (s *Simulation) SeekTo(time uint) timeline.Frame {
+	s.playbackTime = time
+	return s.timeline.GetFrame(time)
+}
+
+// Moves playback one second forward and returns tram position changes
+// recorded at that time, the same way as AdvanceTrams does.
+func (s *Simulation) AdvancePlayback() []tram.TramPositionChange {
+	s.playbackTime++
+	return s.timeline.GetChanges(s.playbackTime)
+}
+
+func (s *Simulation) GetRecordedTimeBounds() (result city.TimeBounds) {
+	result.StartTime, result.EndTime, _ = s.timeline.GetBounds()
+	return
+}
@@ -0,0 +1,44 @@
+package simulation
+
+import (
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/punctuality"
+)
+
+// Returns departures of trams from stops and arrivals at the last stops of their trips
+func (s *Simulation) getStopEvents() []punctuality.StopEvent {
+	events := make([]punctuality.StopEvent, 0)
+
+	for _, route := range s.city.GetTramRoutes() {
+		for _, tramTrip := range route.Trips {
+			tram := s.trams[tramTrip.ID]
+			lastStopIndex := len(tramTrip.Stops) - 1
+
+			for stopIndex, stop := range tramTrip.Stops {
+				time, ok := tram.GetActualDeparture(stopIndex)
+				if stopIndex == lastStopIndex {
+					time, ok = tram.GetActualArrival(stopIndex)
+				}
+
+				if !ok {
+					continue
+				}
+
+				events = append(events, punctuality.StopEvent{
+					TripID:        tramTrip.ID,
+					Route:         route.Name,
+					StopID:        stop.ID,
+					StopIndex:     stopIndex,
+					ScheduledTime: stop.Time,
+					Time:          time,
+				})
+			}
+		}
+	}
+
+	return events
+}
+
+// Returns on-time performance and delays of trams until the current time
+func (s *Simulation) GetPunctualityReport(window punctuality.OnTimeWindow) punctuality.Report {
+	return punctuality.GetReport(s.getStopEvents(), window)
+}
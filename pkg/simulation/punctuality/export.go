@@ -0,0 +1,16 @@
+package punctuality
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func ReportToJSONBuffer(report Report, writer io.Writer) error {
+	jsonReport, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(jsonReport)
+	return err
+}
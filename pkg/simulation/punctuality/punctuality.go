@@ -0,0 +1,259 @@
+package punctuality
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Percentile of delays reported along with the mean delay
+const DELAY_PERCENTILE = 0.95
+
+// Trams are on time when they depart at most EarlyTolerance seconds
+// before and at most LateTolerance seconds after the scheduled time
+type OnTimeWindow struct {
+	EarlyTolerance uint `json:"earlyTolerance"`
+	LateTolerance  uint `json:"lateTolerance"`
+}
+
+var DEFAULT_ON_TIME_WINDOW = OnTimeWindow{
+	EarlyTolerance: 60,
+	LateTolerance:  5 * 60,
+}
+
+// Departure of a trip from a stop, or arrival for the last stop of the trip
+type StopEvent struct {
+	TripID        uint
+	Route         string
+	StopID        uint64
+	StopIndex     int
+	ScheduledTime uint
+	Time          uint
+}
+
+func (e *StopEvent) getDelay() int {
+	return int(e.Time) - int(e.ScheduledTime)
+}
+
+// Delays are given in seconds, negative for early departures
+type DelayStats struct {
+	Count           int     `json:"count"`
+	OnTimeShare     float64 `json:"onTimeShare"`
+	EarlyShare      float64 `json:"earlyShare"`
+	LateShare       float64 `json:"lateShare"`
+	MeanDelay       float64 `json:"meanDelay"`
+	PercentileDelay int     `json:"percentileDelay"`
+}
+
+type RoutePunctuality struct {
+	Route                string     `json:"route"`
+	Delay                DelayStats `json:"delay"`
+	StartedLateTripShare float64    `json:"startedLateTripShare"`
+}
+
+type StopPunctuality struct {
+	StopID uint64     `json:"stopID"`
+	Delay  DelayStats `json:"delay"`
+}
+
+// Hour of the scheduled time, may be greater than 23 for trips after midnight
+type HourPunctuality struct {
+	Hour  uint       `json:"hour"`
+	Delay DelayStats `json:"delay"`
+}
+
+type TripPunctuality struct {
+	TripID     uint       `json:"tripID"`
+	Route      string     `json:"route"`
+	StartDelay int        `json:"startDelay"`
+	Delay      DelayStats `json:"delay"`
+}
+
+// Delay built up by trips of the route between consecutive visited stops
+type SegmentDelay struct {
+	Route                 string  `json:"route"`
+	FromStopID            uint64  `json:"fromStopID"`
+	ToStopID              uint64  `json:"toStopID"`
+	Count                 int     `json:"count"`
+	MeanDelayChange       float64 `json:"meanDelayChange"`
+	PercentileDelayChange int     `json:"percentileDelayChange"`
+}
+
+type Report struct {
+	Window               OnTimeWindow       `json:"window"`
+	Delay                DelayStats         `json:"delay"`
+	StartedLateTripShare float64            `json:"startedLateTripShare"`
+	Routes               []RoutePunctuality `json:"routes"`
+	Stops                []StopPunctuality  `json:"stops"`
+	Hours                []HourPunctuality  `json:"hours"`
+	Trips                []TripPunctuality  `json:"trips"`
+	Segments             []SegmentDelay     `json:"segments"`
+}
+
+type segment struct {
+	route      string
+	fromStopID uint64
+	toStopID   uint64
+}
+
+// Returns punctuality report of trips from their departures from stops recorded so far
+func GetReport(events []StopEvent, window OnTimeWindow) Report {
+	delaysByRoute := make(map[string][]int)
+	delaysByStopID := make(map[uint64][]int)
+	delaysByHour := make(map[uint][]int)
+	eventsByTripID := make(map[uint][]StopEvent)
+	var delays []int
+
+	for _, event := range events {
+		delay := event.getDelay()
+		delays = append(delays, delay)
+		delaysByRoute[event.Route] = append(delaysByRoute[event.Route], delay)
+		delaysByStopID[event.StopID] = append(delaysByStopID[event.StopID], delay)
+		delaysByHour[event.ScheduledTime/3600] = append(delaysByHour[event.ScheduledTime/3600], delay)
+		eventsByTripID[event.TripID] = append(eventsByTripID[event.TripID], event)
+	}
+
+	report := Report{
+		Window:   window,
+		Delay:    getDelayStats(delays, window),
+		Routes:   make([]RoutePunctuality, 0, len(delaysByRoute)),
+		Stops:    make([]StopPunctuality, 0, len(delaysByStopID)),
+		Hours:    make([]HourPunctuality, 0, len(delaysByHour)),
+		Trips:    make([]TripPunctuality, 0, len(eventsByTripID)),
+		Segments: make([]SegmentDelay, 0),
+	}
+
+	startedTripsByRoute := make(map[string]int)
+	startedLateTripsByRoute := make(map[string]int)
+	delayChangesBySegment := make(map[segment][]int)
+
+	for _, tripID := range slices.Sorted(maps.Keys(eventsByTripID)) {
+		tripEvents := eventsByTripID[tripID]
+		slices.SortFunc(tripEvents, func(e1, e2 StopEvent) int {
+			return cmp.Compare(e1.StopIndex, e2.StopIndex)
+		})
+
+		tripDelays := make([]int, len(tripEvents))
+		for i, event := range tripEvents {
+			tripDelays[i] = event.getDelay()
+		}
+
+		route := tripEvents[0].Route
+		tripPunctuality := TripPunctuality{
+			TripID: tripID,
+			Route:  route,
+			Delay:  getDelayStats(tripDelays, window),
+		}
+
+		// trips which skipped their first stop didn't start on schedule
+		if tripEvents[0].StopIndex == 0 {
+			tripPunctuality.StartDelay = tripDelays[0]
+			startedTripsByRoute[route]++
+			if tripDelays[0] > int(window.LateTolerance) {
+				startedLateTripsByRoute[route]++
+			}
+		}
+
+		for i := 1; i < len(tripEvents); i++ {
+			key := segment{route: route, fromStopID: tripEvents[i-1].StopID, toStopID: tripEvents[i].StopID}
+			delayChangesBySegment[key] = append(delayChangesBySegment[key], tripDelays[i]-tripDelays[i-1])
+		}
+
+		report.Trips = append(report.Trips, tripPunctuality)
+	}
+
+	var startedTrips, startedLateTrips int
+	for _, route := range slices.Sorted(maps.Keys(delaysByRoute)) {
+		routePunctuality := RoutePunctuality{
+			Route: route,
+			Delay: getDelayStats(delaysByRoute[route], window),
+		}
+
+		if startedTripsByRoute[route] > 0 {
+			routePunctuality.StartedLateTripShare = float64(startedLateTripsByRoute[route]) / float64(startedTripsByRoute[route])
+		}
+
+		startedTrips += startedTripsByRoute[route]
+		startedLateTrips += startedLateTripsByRoute[route]
+		report.Routes = append(report.Routes, routePunctuality)
+	}
+
+	if startedTrips > 0 {
+		report.StartedLateTripShare = float64(startedLateTrips) / float64(startedTrips)
+	}
+
+	for _, stopID := range slices.Sorted(maps.Keys(delaysByStopID)) {
+		report.Stops = append(report.Stops, StopPunctuality{
+			StopID: stopID,
+			Delay:  getDelayStats(delaysByStopID[stopID], window),
+		})
+	}
+
+	for _, hour := range slices.Sorted(maps.Keys(delaysByHour)) {
+		report.Hours = append(report.Hours, HourPunctuality{
+			Hour:  hour,
+			Delay: getDelayStats(delaysByHour[hour], window),
+		})
+	}
+
+	for key, delayChanges := range delayChangesBySegment {
+		report.Segments = append(report.Segments, SegmentDelay{
+			Route:                 key.route,
+			FromStopID:            key.fromStopID,
+			ToStopID:              key.toStopID,
+			Count:                 len(delayChanges),
+			MeanDelayChange:       getMean(delayChanges),
+			PercentileDelayChange: getPercentile(delayChanges, DELAY_PERCENTILE),
+		})
+	}
+
+	slices.SortFunc(report.Segments, func(s1, s2 SegmentDelay) int {
+		return cmp.Or(
+			cmp.Compare(s1.Route, s2.Route),
+			cmp.Compare(s1.FromStopID, s2.FromStopID),
+			cmp.Compare(s1.ToStopID, s2.ToStopID),
+		)
+	})
+
+	return report
+}
+
+func getDelayStats(delays []int, window OnTimeWindow) DelayStats {
+	stats := DelayStats{Count: len(delays)}
+	if len(delays) == 0 {
+		return stats
+	}
+
+	var earlyCount, lateCount int
+	for _, delay := range delays {
+		if delay < -int(window.EarlyTolerance) {
+			earlyCount++
+		} else if delay > int(window.LateTolerance) {
+			lateCount++
+		}
+	}
+
+	stats.EarlyShare = float64(earlyCount) / float64(len(delays))
+	stats.LateShare = float64(lateCount) / float64(len(delays))
+	stats.OnTimeShare = float64(len(delays)-earlyCount-lateCount) / float64(len(delays))
+	stats.MeanDelay = getMean(delays)
+	stats.PercentileDelay = getPercentile(delays, DELAY_PERCENTILE)
+
+	return stats
+}
+
+func getMean(values []int) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += float64(value)
+	}
+	return sum / float64(len(values))
+}
+
+// Returns the nearest-rank percentile of the values
+func getPercentile(values []int, percentile float64) int {
+	sorted := slices.Sorted(slices.Values(values))
+	rank := int(math.Ceil(percentile * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
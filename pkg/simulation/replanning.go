@@ -0,0 +1,41 @@
+package simulation
+
+import (
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+)
+
+// Live estimates of trips given by the trams running them at the time
+type tramEstimates struct {
+	trams map[uint]*tram.Tram
+	time  uint
+}
+
+func (e tramEstimates) GetEstimatedTime(tripID uint, stopIndex int) (uint, bool) {
+	tram, ok := e.trams[tripID]
+	if !ok || tram.IsStopped() || tram.IsBrokenDown() {
+		return 0, false
+	}
+
+	if _, isDeparted := tram.GetActualDeparture(stopIndex); isDeparted {
+		return 0, false
+	}
+
+	estimatedArrival, ok := tram.GetEstimatedArrival(stopIndex, e.time)
+	if !ok {
+		return 0, false
+	}
+
+	// Trams which haven't departed from the stop yet can't be taken before the current time
+	return max(estimatedArrival, e.time), true
+}
+
+// Passengers re-plan their travel at check times of the replanning model
+// and right after a tram is stopped
+func (s *Simulation) replanPassengers(time uint) {
+	isReplanPending := s.isReplanPending.Swap(false)
+	if s.replanningModel == nil || !s.replanningModel.IsCheckTime(time) && !isReplanPending {
+		return
+	}
+
+	s.passengersStore.ReplanPassengers(s.replanningModel, tramEstimates{trams: s.trams, time: time}, time)
+}
@@ -4,18 +4,27 @@ import (
 	"archive/zip"
 	"context"
 	"fmt"
+	"io"
 	"math"
+	"math/rand/v2"
 	"os"
 	"runtime"
 	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/TNSEngineerEdition/WailsClient/pkg/api"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/headway"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/loadprofile"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/punctuality"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/timeline"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
 	"github.com/oapi-codegen/runtime/types"
 	wails_runtime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -28,13 +37,29 @@ type Simulation struct {
 	tramWorkersState *structs.WorkerState[*tram.Tram, tram.TramPositionChange]
 	controlCenter    controlcenter.ControlCenter
 	time             uint
+	isClaimingNodes  bool
 	passengersStore  *passenger.PassengersStore
+	fleet            vehicle.Fleet
+	blocks           []vehicle.Block
+	depots           []vehicle.Depot
+	deadRuns         map[uint]deadRuns
+	stabledVehicles  map[uint]uint
+	dwellTimeModel   tram.DwellTimeModel
+	failureModel     *tram.FailureModel
+	replanningModel  *passenger.ReplanningModel
+	isReplanPending  atomic.Bool
+	parameters       snapshotParameters
+	seed             uint64
+	timeline         *timeline.Timeline
+	playbackTime     uint
 }
 
 func NewSimulation(apiClient *api.APIClient, city *city.City) Simulation {
 	return Simulation{
-		apiClient: apiClient,
-		city:      city,
+		apiClient:      apiClient,
+		city:           city,
+		timeline:       timeline.NewTimeline(),
+		dwellTimeModel: &tram.DEFAULT_DWELL_TIME_MODEL,
 	}
 }
 
@@ -44,8 +69,11 @@ func (s *Simulation) SetContext(ctx context.Context) {
 
 func (s *Simulation) tramWorker(state *structs.WorkerState[*tram.Tram, tram.TramPositionChange]) {
 	for tram := range state.InputChannel {
-		positionChange, update := tram.Advance(s.time, s.city.GetStopsByID())
-		if update {
+		if s.isClaimingNodes {
+			tram.CheckPreviousTrip()
+			tram.PlanHolding(s.time)
+			tram.ClaimNodesAhead(s.time)
+		} else if positionChange, update := tram.Advance(s.time, s.city.GetStopsByID()); update {
 			state.OutputChannel <- positionChange
 		}
 
@@ -53,15 +81,56 @@ func (s *Simulation) tramWorker(state *structs.WorkerState[*tram.Tram, tram.Tram
 	}
 }
 
+func (s *Simulation) runTramWorkers() {
+	s.tramWorkersState.WaitGroup.Add(len(s.trams))
+	for _, tram := range s.trams {
+		s.tramWorkersState.InputChannel <- tram
+	}
+
+	s.tramWorkersState.WaitGroup.Wait()
+}
+
 func (s *Simulation) resetTrams() {
 	trams := make(map[uint]*tram.Tram)
 
 	for _, route := range s.city.GetTramRoutes() {
 		for _, trip := range route.Trips {
-			trams[trip.ID] = tram.NewTram(trip.ID, &route, &trip, &s.controlCenter, s.passengersStore)
+			trams[trip.ID] = tram.NewTram(
+				trip.ID,
+				&route,
+				&trip,
+				&s.controlCenter,
+				s.passengersStore,
+				s.fleet.GetVehicleType(route.Name, trip.ID),
+				s.dwellTimeModel,
+				s.failureModel,
+				structs.NewRandomSource(s.seed, structs.TramRandomStream, uint64(trip.ID)),
+			)
 		}
 	}
 
+	// All trips of a block are done by the same vehicle
+	for _, block := range s.blocks {
+		firstTram := trams[block.TripIDs[0]]
+		deadRuns, hasDepot := s.deadRuns[block.ID]
+		var previousTram *tram.Tram
+
+		for _, tripID := range block.TripIDs {
+			tram := trams[tripID]
+			tram.SetVehicle(block.ID, previousTram)
+			if hasDepot {
+				tram.SetDepot(deadRuns.depotID, deadRuns.depotNodeID)
+			}
+			previousTram = tram
+		}
+
+		if hasDepot {
+			firstTram.SetPullOut(deadRuns.pullOut, deadRuns.pullOutTime)
+			previousTram.SetPullIn(deadRuns.pullIn)
+		}
+	}
+
+	s.linkTramsAtStops(trams)
 	s.trams = trams
 }
 
@@ -69,14 +138,27 @@ func (s *Simulation) ResetSimulation() {
 	s.passengersStore.ResetPassengers()
 	s.resetTrams()
 	s.city.Reset()
+	s.controlCenter.ResetDisruptions()
+	s.resetTimeline()
 }
 
 type SimulationParameters struct {
-	CityID         string       `json:"cityID"`
-	Weekday        *api.Weekday `json:"weekday,omitempty"`
-	Date           *types.Date  `json:"date,omitempty"`
-	CustomSchedule []byte       `json:"customSchedule,omitempty"`
-	PassengerModel []byte       `json:"passengerModel,omitempty"`
+	CityID         string                        `json:"cityID"`
+	Weekday        *api.Weekday                  `json:"weekday,omitempty"`
+	Date           *types.Date                   `json:"date,omitempty"`
+	CustomSchedule []byte                        `json:"customSchedule,omitempty"`
+	PassengerModel []byte                        `json:"passengerModel,omitempty"`
+	ODMatrix       []byte                        `json:"odMatrix,omitempty"`
+	DemandModel    *passenger.RandomDemandModel  `json:"demandModel,omitempty"`
+	GravityModel   *passenger.GravityDemandModel `json:"gravityModel,omitempty"`
+	ChoiceModel    *travelplan.ChoiceModel       `json:"choiceModel,omitempty"`
+	Replanning     *passenger.ReplanningModel    `json:"replanningModel,omitempty"`
+	VehicleTypes   []byte                        `json:"vehicleTypes,omitempty"`
+	Blocks         []byte                        `json:"blocks,omitempty"`
+	Depots         []vehicle.Depot               `json:"depots,omitempty"`
+	DwellTimeModel *tram.LinearDwellTimeModel    `json:"dwellTimeModel,omitempty"`
+	FailureModel   *tram.FailureModel            `json:"failureModel,omitempty"`
+	Seed           *uint64                       `json:"seed,omitempty"`
 }
 
 func (s *Simulation) InitializeCity(parameters SimulationParameters) string {
@@ -94,22 +176,151 @@ func (s *Simulation) InitializeCity(parameters SimulationParameters) string {
 		return err.Error()
 	}
 
-	var passengerModelData []passenger.PassengerModelData
-	if len(parameters.PassengerModel) == 0 {
-		passengerModelData = passenger.GenerateRandomPassengers(s.city)
+	if err := s.InitializeFleet(parameters); err != nil {
+		return err.Error()
+	}
+
+	if err := s.InitializePassengers(parameters); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// Assigns vehicle types to trips, chains trips into blocks, sets depots, the dwell time model
+// and the failure model of trams.
+// Default vehicle type and dwell time model are used if none are given,
+// blocks are inferred from turnarounds at terminals if no blocks file is given.
+func (s *Simulation) InitializeFleet(parameters SimulationParameters) error {
+	dwellTimeModel := tram.DEFAULT_DWELL_TIME_MODEL
+	if parameters.DwellTimeModel != nil {
+		if err := parameters.DwellTimeModel.Validate(); err != nil {
+			return fmt.Errorf("invalid dwell time model: %w", err)
+		}
+		dwellTimeModel = *parameters.DwellTimeModel
+	}
+
+	if parameters.FailureModel != nil {
+		if err := parameters.FailureModel.Validate(); err != nil {
+			return fmt.Errorf("invalid failure model: %w", err)
+		}
+	}
+
+	fleet := vehicle.Fleet{}
+	if len(parameters.VehicleTypes) > 0 {
+		var err error
+		if fleet, err = vehicle.FleetFromJSON(parameters.VehicleTypes); err != nil {
+			return err
+		}
+	}
+
+	blocks := vehicle.InferBlocks(s.city, &fleet)
+	if len(parameters.Blocks) > 0 {
+		var err error
+		if blocks, err = vehicle.BlocksFromCSV(s.city, parameters.Blocks); err != nil {
+			return err
+		}
+	}
+
+	if err := fleet.ValidateBlocks(s.city, blocks); err != nil {
+		return err
+	}
+
+	if err := vehicle.ValidateDepots(s.city, parameters.Depots); err != nil {
+		return err
+	}
+
+	s.fleet = fleet
+	s.blocks = blocks
+	s.depots = parameters.Depots
+	s.failureModel = parameters.FailureModel
+	s.dwellTimeModel = &dwellTimeModel
+	s.parameters.setFleetParameters(parameters)
+	return nil
+}
+
+func (s *Simulation) InitializePassengers(parameters SimulationParameters) error {
+	if parameters.Seed != nil {
+		s.seed = *parameters.Seed
 	} else {
-		passengerModelData, err = passenger.GeneratePassengersFromModel(s.city, parameters.PassengerModel)
+		// Keep the seed representable as a JavaScript number
+		s.seed = rand.Uint64N(1 << 53)
 	}
 
-	passengers := passenger.PassengersFromModelData(s.city, passengerModelData, 0)
+	var passengerModelData []passenger.PassengerModelData
+	var err error
 
-	if err != nil {
-		return err.Error()
+	random := structs.NewRandom(s.seed, structs.PassengerGeneratorRandomStream, 0)
+
+	if parameters.ChoiceModel != nil {
+		if err := parameters.ChoiceModel.Validate(); err != nil {
+			return fmt.Errorf("invalid choice model: %w", err)
+		}
+	}
+
+	if parameters.Replanning != nil {
+		if err := parameters.Replanning.Validate(); err != nil {
+			return fmt.Errorf("invalid replanning model: %w", err)
+		}
+	}
+	s.replanningModel = parameters.Replanning
+
+	if parameters.DemandModel != nil && parameters.GravityModel != nil {
+		return fmt.Errorf("demand model and gravity model can't be used together")
+	}
+
+	isPassengerDataGiven := len(parameters.PassengerModel) > 0 || len(parameters.ODMatrix) > 0
+	if parameters.DemandModel != nil && isPassengerDataGiven {
+		return fmt.Errorf("demand model can't be used together with passenger model or OD matrix")
+	}
+
+	if parameters.GravityModel != nil && isPassengerDataGiven {
+		return fmt.Errorf("gravity model can't be used together with passenger model or OD matrix")
+	}
+
+	if parameters.GravityModel != nil {
+		if err := parameters.GravityModel.Validate(s.city); err != nil {
+			return fmt.Errorf("invalid gravity model: %w", err)
+		}
+		passengerModelData = passenger.GenerateGravityPassengers(s.city, parameters.GravityModel, random)
+	} else if !isPassengerDataGiven {
+		demandModel := passenger.DEFAULT_RANDOM_DEMAND_MODEL
+		if parameters.DemandModel != nil {
+			if err := parameters.DemandModel.Validate(s.city); err != nil {
+				return fmt.Errorf("invalid demand model: %w", err)
+			}
+			demandModel = *parameters.DemandModel
+		}
+		passengerModelData = passenger.GenerateRandomPassengers(s.city, &demandModel, random)
+	} else if len(parameters.PassengerModel) > 0 {
+		if passengerModelData, err = passenger.GeneratePassengersFromModel(s.city, parameters.PassengerModel); err != nil {
+			return err
+		}
+	}
+
+	// Passengers from OD matrix are added after the ones given one by one
+	if len(parameters.ODMatrix) > 0 {
+		odMatrixData, err := passenger.GeneratePassengersFromODMatrix(
+			s.city,
+			parameters.ODMatrix,
+			uint64(len(passengerModelData))+1,
+			random,
+		)
+		if err != nil {
+			return err
+		}
+		passengerModelData = append(passengerModelData, odMatrixData...)
 	}
 
+	passengers := passenger.PassengersFromModelData(s.city, passengerModelData, parameters.ChoiceModel, 0, s.seed)
 	s.passengersStore = passenger.NewPassengersStore(s.city, passengers)
+	s.parameters.setPassengerParameters(parameters)
 
-	return ""
+	return nil
+}
+
+func (s *Simulation) GetSeed() uint64 {
+	return s.seed
 }
 
 func (s *Simulation) InitializeSimulation(tramWorkerCount uint) string {
@@ -117,7 +328,13 @@ func (s *Simulation) InitializeSimulation(tramWorkerCount uint) string {
 		panic("City data is not fetched")
 	}
 
-	s.controlCenter = controlcenter.NewControlCenter(s.city)
+	controlCenter, err := controlcenter.NewControlCenter(s.city)
+	if err != nil {
+		return err.Error()
+	}
+
+	s.controlCenter = controlCenter
+	s.planDeadRuns()
 	s.ResetSimulation()
 
 	if s.tramWorkersState != nil {
@@ -159,22 +376,36 @@ func (s *Simulation) AdvanceTrams(time uint) (result []tram.TramPositionChange)
 
 	s.passengersStore.DespawnPassengersAtTime(time)
 	s.passengersStore.SpawnPassengersAtTime(time)
+	s.controlCenter.UpdateDisruptions(time)
+	s.replanPassengers(time)
 
-	s.tramWorkersState.WaitGroup.Add(len(s.trams))
-	for _, tram := range s.trams {
-		s.tramWorkersState.InputChannel <- tram
-	}
+	// Nodes are claimed by all trams before advancing, so that
+	// the order of processing trams doesn't affect the results
+	s.isClaimingNodes = true
+	s.runTramWorkers()
 
-	s.tramWorkersState.WaitGroup.Wait()
+	s.isClaimingNodes = false
+	s.runTramWorkers()
 
 	result = make([]tram.TramPositionChange, 0)
 	for range len(s.tramWorkersState.OutputChannel) {
 		result = append(result, <-s.tramWorkersState.OutputChannel)
 	}
 
+	s.timeline.Record(time, result, s.passengersStore.GetPassengerCounts())
+
 	return result
 }
 
+func (s *Simulation) AreTramsFinished() bool {
+	for _, tram := range s.trams {
+		if !tram.IsFinished() {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Simulation) GetTramDetails(id uint) tram.TramDetails {
 	if tram, ok := s.trams[id]; ok {
 		return tram.GetDetails(s.city, s.time)
@@ -193,6 +424,7 @@ func (s *Simulation) StopResumeTram(id uint) tram.TramDetails {
 		tram.ResumeTram(s.time)
 	} else {
 		tram.StopTram()
+		s.isReplanPending.Store(true)
 	}
 
 	return tram.GetDetails(s.city, s.time)
@@ -229,13 +461,14 @@ func (s *Simulation) GetArrivalsForStop(stopID uint64, count int) []Arrival {
 		}
 
 		tram := s.trams[arrival.TripID]
-		if tram.TripDetails.Index > arrival.StopIndex {
+		if tram.TripDetails.Index > arrival.StopIndex || tram.IsPullingIn() || tram.TripDetails.Skipped[arrival.StopIndex] {
 			continue
 		}
 
 		var expectedTime uint
 		if tram.TripDetails.Index < arrival.StopIndex || !tram.IsAtStop() {
-			expectedTime = tram.GetEstimatedArrival(arrival.StopIndex, s.time) - s.time
+			estimatedArrival, _ := tram.GetEstimatedArrival(arrival.StopIndex, s.time)
+			expectedTime = estimatedArrival - s.time
 		}
 
 		arrivals = append(arrivals, Arrival{
@@ -288,36 +521,123 @@ func (s *Simulation) ExportToFile() string {
 	}
 	defer file.Close()
 
-	zipWriter := zip.NewWriter(file)
-	defer zipWriter.Close()
+	if err := s.ExportToWriter(file); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (s *Simulation) ExportToWriter(writer io.Writer) error {
+	zipWriter := zip.NewWriter(writer)
 
 	// city data
-	if cityDataZipFileWriter, err := zipWriter.Create("city_data.json"); err != nil {
-		return err.Error()
+	if cityDataZipFileWriter, err := zipWriter.Create(city.CITY_DATA_FILENAME); err != nil {
+		return err
 	} else if err := s.city.CityDataToJSONBuffer(cityDataZipFileWriter); err != nil {
-		return err.Error()
+		return err
 	}
 
 	// trams
 	if tramZipFileWriter, err := zipWriter.Create("trams.csv"); err != nil {
-		return err.Error()
+		return err
 	} else if err := tram.TramsToCSVBuffer(s.trams, tramZipFileWriter); err != nil {
-		return err.Error()
+		return err
 	}
 
 	// passengers
 	if passengerZipFileWriter, err := zipWriter.Create("passengers.csv"); err != nil {
-		return err.Error()
+		return err
 	} else if err := s.passengersStore.PassengersToCSVBuffer(passengerZipFileWriter); err != nil {
-		return err.Error()
+		return err
 	}
 
 	// passenger trips
 	if passengerTripsZipFileWriter, err := zipWriter.Create("passenger_trips.csv"); err != nil {
-		return err.Error()
+		return err
 	} else if err := s.passengersStore.PassengerTripsToCSVBuffer(passengerTripsZipFileWriter); err != nil {
-		return err.Error()
+		return err
 	}
 
-	return ""
+	// denied boardings
+	if deniedBoardingsZipFileWriter, err := zipWriter.Create("denied_boardings.csv"); err != nil {
+		return err
+	} else if err := s.passengersStore.DeniedBoardingsToCSVBuffer(deniedBoardingsZipFileWriter); err != nil {
+		return err
+	}
+
+	// re-planned travel of passengers
+	if replansZipFileWriter, err := zipWriter.Create("replans.csv"); err != nil {
+		return err
+	} else if err := s.passengersStore.ReplansToCSVBuffer(replansZipFileWriter); err != nil {
+		return err
+	}
+
+	// vehicles
+	if vehiclesZipFileWriter, err := zipWriter.Create("vehicles.csv"); err != nil {
+		return err
+	} else if err := tram.VehiclesToCSVBuffer(s.blocks, s.trams, vehiclesZipFileWriter); err != nil {
+		return err
+	}
+
+	// depots
+	if depotsZipFileWriter, err := zipWriter.Create("depots.csv"); err != nil {
+		return err
+	} else if err := s.depotOccupancyToCSVBuffer(depotsZipFileWriter); err != nil {
+		return err
+	}
+
+	// incidents
+	if incidentsZipFileWriter, err := zipWriter.Create("incidents.csv"); err != nil {
+		return err
+	} else if err := tram.IncidentsToCSVBuffer(s.trams, incidentsZipFileWriter); err != nil {
+		return err
+	}
+
+	// headways
+	headwayStats := s.getHeadwayStats()
+	if headwaysZipFileWriter, err := zipWriter.Create("headways.csv"); err != nil {
+		return err
+	} else if err := headway.HeadwaysToCSVBuffer(headwayStats, headwaysZipFileWriter); err != nil {
+		return err
+	}
+
+	// bunching events
+	if bunchingZipFileWriter, err := zipWriter.Create("bunching.csv"); err != nil {
+		return err
+	} else if err := headway.BunchingEventsToCSVBuffer(headwayStats, bunchingZipFileWriter); err != nil {
+		return err
+	}
+
+	// load profiles
+	routeLoadProfiles, tripLoadProfiles := s.getLoadProfiles()
+	if loadProfilesZipFileWriter, err := zipWriter.Create("load_profiles.csv"); err != nil {
+		return err
+	} else if err := loadprofile.RouteLoadProfilesToCSVBuffer(routeLoadProfiles, loadProfilesZipFileWriter); err != nil {
+		return err
+	}
+
+	// trip loads
+	if tripLoadsZipFileWriter, err := zipWriter.Create("trip_loads.csv"); err != nil {
+		return err
+	} else if err := loadprofile.TripLoadProfilesToCSVBuffer(tripLoadProfiles, tripLoadsZipFileWriter); err != nil {
+		return err
+	}
+
+	// punctuality
+	punctualityReport := s.GetPunctualityReport(punctuality.DEFAULT_ON_TIME_WINDOW)
+	if punctualityZipFileWriter, err := zipWriter.Create("punctuality.json"); err != nil {
+		return err
+	} else if err := punctuality.ReportToJSONBuffer(punctualityReport, punctualityZipFileWriter); err != nil {
+		return err
+	}
+
+	// disruptions
+	if disruptionsZipFileWriter, err := zipWriter.Create("disruptions.csv"); err != nil {
+		return err
+	} else if err := s.controlCenter.DisruptionsToCSVBuffer(disruptionsZipFileWriter); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
 }
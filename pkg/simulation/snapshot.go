@@ -0,0 +1,217 @@
+package simulation
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+	wails_runtime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Version of the snapshot format, increase when the format changes
+const SNAPSHOT_VERSION = 2
+
+// Parameters hash is the hash of fleet, blocks, depots, dwell time, failure, choice
+// and replanning parameters, which have to be the same when restoring the snapshot.
+type SimulationSnapshot struct {
+	Version        int                               `json:"version"`
+	CityID         string                            `json:"cityID"`
+	ParametersHash string                            `json:"parametersHash"`
+	Seed           uint64                            `json:"seed"`
+	Time           uint                              `json:"time"`
+	Trams          []tram.TramSnapshot               `json:"trams"`
+	Passengers     passenger.PassengersStoreSnapshot `json:"passengers"`
+	BlockedNodes   map[uint64]uint                   `json:"blockedNodes"`
+	Disruptions    []controlcenter.Disruption        `json:"disruptions,omitempty"`
+	Holding        []controlcenter.HoldingControl    `json:"holdingControls,omitempty"`
+}
+
+func (s *Simulation) GetTime() uint {
+	return s.time
+}
+
+func (s *Simulation) getSnapshot() SimulationSnapshot {
+	snapshot := SimulationSnapshot{
+		Version:        SNAPSHOT_VERSION,
+		CityID:         s.city.CityID,
+		ParametersHash: s.parameters.getHash(),
+		Seed:           s.seed,
+		Time:           s.time,
+		Trams:          make([]tram.TramSnapshot, 0, len(s.trams)),
+		Passengers:     s.passengersStore.GetSnapshot(),
+		BlockedNodes:   make(map[uint64]uint),
+		Disruptions:    s.controlCenter.GetDisruptions(),
+		Holding:        s.controlCenter.GetHoldingControls(),
+	}
+
+	for _, tram := range s.trams {
+		snapshot.Trams = append(snapshot.Trams, tram.GetSnapshot())
+	}
+
+	slices.SortFunc(snapshot.Trams, func(t1, t2 tram.TramSnapshot) int {
+		return cmp.Compare(t1.ID, t2.ID)
+	})
+
+	for nodeID, node := range s.city.GetNodesByID() {
+		if tramID := node.GetBlockingTramID(); tramID != 0 {
+			snapshot.BlockedNodes[nodeID] = tramID
+		}
+	}
+
+	return snapshot
+}
+
+func (s *Simulation) restoreSnapshot(snapshot SimulationSnapshot) error {
+	if snapshot.Version != SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported snapshot version %d, expected %d", snapshot.Version, SNAPSHOT_VERSION)
+	}
+
+	if snapshot.CityID != s.city.CityID {
+		return fmt.Errorf("snapshot of city %q can't be loaded to city %q", snapshot.CityID, s.city.CityID)
+	}
+
+	if snapshot.ParametersHash != s.parameters.getHash() {
+		return fmt.Errorf("snapshot was saved with different fleet, blocks, depots, dwell time, failure, choice or replanning parameters")
+	}
+
+	if s.tramWorkersState == nil {
+		return fmt.Errorf("simulation is not initialized")
+	}
+
+	if len(snapshot.Trams) != len(s.trams) {
+		return fmt.Errorf("expected %d trams in snapshot, got %d", len(s.trams), len(snapshot.Trams))
+	}
+
+	// Everything is validated before changing the state, so that it's not restored partially
+	passengersStore, err := passenger.PassengersStoreFromSnapshot(s.city, snapshot.Passengers)
+	if err != nil {
+		return err
+	}
+
+	nodesByID := s.city.GetNodesByID()
+	restoredTramIDs := structs.NewSet[uint]()
+	for _, tramSnapshot := range snapshot.Trams {
+		tram, ok := s.trams[tramSnapshot.ID]
+		if !ok {
+			return fmt.Errorf("tram %d not found", tramSnapshot.ID)
+		}
+
+		if restoredTramIDs.Includes(tramSnapshot.ID) {
+			return fmt.Errorf("tram %d is in snapshot more than once", tramSnapshot.ID)
+		}
+		restoredTramIDs.Add(tramSnapshot.ID)
+
+		if err := tram.ValidateSnapshot(tramSnapshot, nodesByID, passengersStore); err != nil {
+			return err
+		}
+	}
+
+	for nodeID := range snapshot.BlockedNodes {
+		if _, ok := nodesByID[nodeID]; !ok {
+			return fmt.Errorf("node %d not found", nodeID)
+		}
+	}
+
+	if err := s.controlCenter.ValidateDisruptions(snapshot.Disruptions); err != nil {
+		return err
+	}
+
+	if err := s.controlCenter.SetHoldingControls(s.city, snapshot.Holding); err != nil {
+		return err
+	}
+
+	if err := s.controlCenter.SetDisruptions(snapshot.Disruptions); err != nil {
+		return err
+	}
+
+	s.seed = snapshot.Seed
+	s.passengersStore = passengersStore
+	s.resetTrams()
+	s.city.Reset()
+
+	for _, tramSnapshot := range snapshot.Trams {
+		if err := s.trams[tramSnapshot.ID].RestoreSnapshot(tramSnapshot, nodesByID, passengersStore); err != nil {
+			return err
+		}
+	}
+
+	for nodeID, tramID := range snapshot.BlockedNodes {
+		nodesByID[nodeID].ForceBlocking(tramID)
+	}
+
+	s.time = snapshot.Time
+	s.resetTimeline()
+
+	return nil
+}
+
+func (s *Simulation) SaveSnapshotToWriter(writer io.Writer) error {
+	return json.NewEncoder(writer).Encode(s.getSnapshot())
+}
+
+// Restores the state of the simulation from a snapshot. Simulation has
+// to be initialized with the same city before loading the snapshot.
+func (s *Simulation) LoadSnapshotFromReader(reader io.Reader) error {
+	var snapshot SimulationSnapshot
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil {
+		return fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	return s.restoreSnapshot(snapshot)
+}
+
+func (s *Simulation) SaveSnapshot() string {
+	filename, err := wails_runtime.SaveFileDialog(s.ctx, wails_runtime.SaveDialogOptions{
+		DefaultFilename:      fmt.Sprintf("%s-snapshot-%d.json", s.city.CityID, time.Now().Unix()),
+		CanCreateDirectories: true,
+		Filters: []wails_runtime.FileFilter{
+			{DisplayName: "JSON file", Pattern: "*.json"},
+		},
+	})
+	if err != nil {
+		return err.Error()
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	if err := s.SaveSnapshotToWriter(file); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (s *Simulation) LoadSnapshot() string {
+	filename, err := wails_runtime.OpenFileDialog(s.ctx, wails_runtime.OpenDialogOptions{
+		Filters: []wails_runtime.FileFilter{
+			{DisplayName: "JSON file", Pattern: "*.json"},
+		},
+	})
+	if err != nil {
+		return err.Error()
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	if err := s.LoadSnapshotFromReader(file); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
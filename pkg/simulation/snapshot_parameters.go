@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/travelplan"
+)
+
+// Parameters of the simulation which aren't saved in snapshots, but have to be
+// the same when restoring a snapshot. Snapshots contain only their hash.
+type snapshotParameters struct {
+	VehicleTypes   []byte                     `json:"vehicleTypes"`
+	Blocks         []byte                     `json:"blocks"`
+	Depots         []vehicle.Depot            `json:"depots"`
+	DwellTimeModel *tram.LinearDwellTimeModel `json:"dwellTimeModel"`
+	FailureModel   *tram.FailureModel         `json:"failureModel"`
+	ChoiceModel    *travelplan.ChoiceModel    `json:"choiceModel"`
+	Replanning     *passenger.ReplanningModel `json:"replanningModel"`
+}
+
+func (p *snapshotParameters) setFleetParameters(parameters SimulationParameters) {
+	p.VehicleTypes = parameters.VehicleTypes
+	p.Blocks = parameters.Blocks
+	p.Depots = parameters.Depots
+	p.DwellTimeModel = parameters.DwellTimeModel
+	p.FailureModel = parameters.FailureModel
+}
+
+func (p *snapshotParameters) setPassengerParameters(parameters SimulationParameters) {
+	p.ChoiceModel = parameters.ChoiceModel
+	p.Replanning = parameters.Replanning
+}
+
+// Returns SHA-256 hash of the parameters encoded as JSON
+func (p *snapshotParameters) getHash() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
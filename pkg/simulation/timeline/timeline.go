@@ -0,0 +1,257 @@
+package timeline
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/tram"
+)
+
+// Full state is saved every KEYFRAME_INTERVAL seconds, so that building
+// a frame requires replaying at most KEYFRAME_INTERVAL ticks.
+const KEYFRAME_INTERVAL = 5 * 60 // 5 minutes
+
+// Compact version of tram.TramPositionChange
+type tramRecord struct {
+	tramID            uint32
+	lat, lon, azimuth float32
+	delay             uint16
+	state             tram.TramState
+}
+
+type passengerCountRecord struct {
+	stopID uint64
+	count  uint32
+}
+
+type tick struct {
+	time            uint
+	trams           []tramRecord
+	passengerCounts []passengerCountRecord
+}
+
+type keyframe struct {
+	// index of the last tick included in the keyframe, -1 for the initial state
+	tickIndex       int
+	time            uint
+	trams           map[uint32]tramRecord
+	passengerCounts map[uint64]uint32
+}
+
+type Frame struct {
+	Time            uint                      `json:"time"`
+	Trams           []tram.TramPositionChange `json:"trams"`
+	PassengerCounts map[uint64]uint           `json:"passengerCounts"`
+}
+
+type Timeline struct {
+	ticks                  []tick
+	keyframes              []keyframe
+	currentTrams           map[uint32]tramRecord
+	currentPassengerCounts map[uint64]uint32
+	isRecording            bool
+	mu                     sync.RWMutex
+}
+
+func NewTimeline() *Timeline {
+	timeline := &Timeline{isRecording: true}
+	timeline.Reset(0, nil, nil)
+	return timeline
+}
+
+func newTramRecord(change tram.TramPositionChange) tramRecord {
+	return tramRecord{
+		tramID:  uint32(change.TramID),
+		lat:     change.Lat,
+		lon:     change.Lon,
+		azimuth: change.Azimuth,
+		delay:   uint16(min(change.Delay, 1<<16-1)),
+		state:   change.State,
+	}
+}
+
+func (r tramRecord) toPositionChange() tram.TramPositionChange {
+	return tram.TramPositionChange{
+		TramID:  uint(r.tramID),
+		Lat:     r.lat,
+		Lon:     r.lon,
+		Azimuth: r.azimuth,
+		State:   r.state,
+		Delay:   uint(r.delay),
+	}
+}
+
+// Removes all recorded ticks and sets the state from which the recording starts.
+func (t *Timeline) Reset(time uint, positions []tram.TramPositionChange, passengerCounts map[uint64]uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ticks = make([]tick, 0)
+	t.currentTrams = make(map[uint32]tramRecord, len(positions))
+	t.currentPassengerCounts = make(map[uint64]uint32, len(passengerCounts))
+
+	for _, position := range positions {
+		t.currentTrams[uint32(position.TramID)] = newTramRecord(position)
+	}
+
+	for stopID, count := range passengerCounts {
+		if count > 0 {
+			t.currentPassengerCounts[stopID] = uint32(count)
+		}
+	}
+
+	t.keyframes = []keyframe{t.newKeyframe(-1, time)}
+}
+
+// Enables or disables recording ticks. Ticks recorded so far are kept,
+// recording should be enabled only together with resetting the timeline.
+func (t *Timeline) SetRecording(isRecording bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.isRecording = isRecording
+}
+
+func (t *Timeline) newKeyframe(tickIndex int, time uint) keyframe {
+	return keyframe{
+		tickIndex:       tickIndex,
+		time:            time,
+		trams:           maps.Clone(t.currentTrams),
+		passengerCounts: maps.Clone(t.currentPassengerCounts),
+	}
+}
+
+// Records tram position changes and passenger counts at stops after advancing the simulation.
+// Ticks have to be recorded in increasing order of time. Nothing is recorded when recording is disabled.
+func (t *Timeline) Record(time uint, changes []tram.TramPositionChange, passengerCounts map[uint64]uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.isRecording {
+		return
+	}
+
+	recordedTick := tick{
+		time:  time,
+		trams: make([]tramRecord, 0, len(changes)),
+	}
+
+	for _, change := range changes {
+		recordedTick.trams = append(recordedTick.trams, newTramRecord(change))
+	}
+
+	for stopID, count := range passengerCounts {
+		if t.currentPassengerCounts[stopID] != uint32(count) {
+			recordedTick.passengerCounts = append(recordedTick.passengerCounts, passengerCountRecord{
+				stopID: stopID,
+				count:  uint32(count),
+			})
+		}
+	}
+
+	t.ticks = append(t.ticks, recordedTick)
+	applyTick(&recordedTick, t.currentTrams, t.currentPassengerCounts)
+
+	if lastKeyframe := t.keyframes[len(t.keyframes)-1]; time >= lastKeyframe.time+KEYFRAME_INTERVAL {
+		t.keyframes = append(t.keyframes, t.newKeyframe(len(t.ticks)-1, time))
+	}
+}
+
+func applyTick(recordedTick *tick, trams map[uint32]tramRecord, passengerCounts map[uint64]uint32) {
+	for _, record := range recordedTick.trams {
+		// Trams which finished their trip are removed from the map
+		if record.state == tram.StateTripFinished {
+			delete(trams, record.tramID)
+		} else {
+			trams[record.tramID] = record
+		}
+	}
+
+	for _, record := range recordedTick.passengerCounts {
+		if record.count == 0 {
+			delete(passengerCounts, record.stopID)
+		} else {
+			passengerCounts[record.stopID] = record.count
+		}
+	}
+}
+
+// Returns index of the last tick recorded at or before the given time, -1 if there is no such tick
+func (t *Timeline) findTickIndex(time uint) int {
+	return sort.Search(len(t.ticks), func(i int) bool {
+		return t.ticks[i].time > time
+	}) - 1
+}
+
+// Returns state of all trams and passenger counts at stops at the given time
+func (t *Timeline) GetFrame(time uint) Frame {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tickIndex := t.findTickIndex(time)
+
+	keyframeIndex := sort.Search(len(t.keyframes), func(i int) bool {
+		return t.keyframes[i].tickIndex > tickIndex
+	}) - 1
+	keyframe := t.keyframes[max(keyframeIndex, 0)]
+
+	trams := maps.Clone(keyframe.trams)
+	passengerCounts := maps.Clone(keyframe.passengerCounts)
+	for i := keyframe.tickIndex + 1; i <= tickIndex; i++ {
+		applyTick(&t.ticks[i], trams, passengerCounts)
+	}
+
+	frame := Frame{
+		Time:            time,
+		Trams:           make([]tram.TramPositionChange, 0, len(trams)),
+		PassengerCounts: make(map[uint64]uint, len(passengerCounts)),
+	}
+
+	for _, record := range trams {
+		frame.Trams = append(frame.Trams, record.toPositionChange())
+	}
+
+	slices.SortFunc(frame.Trams, func(t1, t2 tram.TramPositionChange) int {
+		return cmp.Compare(t1.TramID, t2.TramID)
+	})
+
+	for stopID, count := range passengerCounts {
+		frame.PassengerCounts[stopID] = uint(count)
+	}
+
+	return frame
+}
+
+// Returns tram position changes recorded exactly at the given time
+func (t *Timeline) GetChanges(time uint) []tram.TramPositionChange {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]tram.TramPositionChange, 0)
+
+	tickIndex := t.findTickIndex(time)
+	if tickIndex < 0 || t.ticks[tickIndex].time != time {
+		return result
+	}
+
+	for _, record := range t.ticks[tickIndex].trams {
+		result = append(result, record.toPositionChange())
+	}
+
+	return result
+}
+
+// Returns time of the first and the last recorded tick
+func (t *Timeline) GetBounds() (startTime, endTime uint, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.ticks) == 0 {
+		return 0, 0, false
+	}
+
+	return t.ticks[0].time, t.ticks[len(t.ticks)-1].time, true
+}
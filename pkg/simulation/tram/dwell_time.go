@@ -0,0 +1,72 @@
+package tram
+
+import (
+	"fmt"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
+)
+
+// Decides how long trams stay at stops. Passengers board and alight one by one,
+// each of them taking the returned amount of seconds of the tram's time at the stop.
+type DwellTimeModel interface {
+	// Time in seconds the tram stays at a stop, even if no passengers change
+	GetMinimumDwellTime() uint
+	// Time in seconds needed for a passenger to board the tram carrying passengerCount passengers
+	GetBoardingTime(vehicleType *vehicle.VehicleType, passengerCount uint) float32
+	// Time in seconds needed for a passenger to alight from the tram carrying passengerCount passengers
+	GetAlightingTime(vehicleType *vehicle.VehicleType, passengerCount uint) float32
+}
+
+// Passengers change through all doors at the same time, and each of them takes
+// longer when standing places in the tram are taken.
+type LinearDwellTimeModel struct {
+	DoorCount uint `json:"doorCount"`
+	// seconds per passenger using a single door
+	BoardingTime  float32 `json:"boardingTime"`
+	AlightingTime float32 `json:"alightingTime"`
+	// extra part of boarding and alighting time when all standing places are taken
+	CrowdingFriction float32 `json:"crowdingFriction"`
+	MinimumDwellTime uint    `json:"minimumDwellTime"`
+}
+
+var DEFAULT_DWELL_TIME_MODEL = LinearDwellTimeModel{
+	DoorCount:        4,
+	BoardingTime:     2.0,
+	AlightingTime:    1.5,
+	CrowdingFriction: 0.5,
+	MinimumDwellTime: 15,
+}
+
+func (m *LinearDwellTimeModel) GetMinimumDwellTime() uint {
+	return m.MinimumDwellTime
+}
+
+func (m *LinearDwellTimeModel) getCrowdingFactor(vehicleType *vehicle.VehicleType, passengerCount uint) float32 {
+	if passengerCount <= vehicleType.SeatedCapacity || vehicleType.StandingCapacity == 0 {
+		return 1
+	}
+
+	standingPassengers := min(passengerCount-vehicleType.SeatedCapacity, vehicleType.StandingCapacity)
+	return 1 + m.CrowdingFriction*float32(standingPassengers)/float32(vehicleType.StandingCapacity)
+}
+
+func (m *LinearDwellTimeModel) GetBoardingTime(vehicleType *vehicle.VehicleType, passengerCount uint) float32 {
+	return m.BoardingTime / float32(m.DoorCount) * m.getCrowdingFactor(vehicleType, passengerCount)
+}
+
+func (m *LinearDwellTimeModel) GetAlightingTime(vehicleType *vehicle.VehicleType, passengerCount uint) float32 {
+	return m.AlightingTime / float32(m.DoorCount) * m.getCrowdingFactor(vehicleType, passengerCount)
+}
+
+func (m *LinearDwellTimeModel) Validate() error {
+	switch {
+	case m.DoorCount == 0:
+		return fmt.Errorf("door count has to be positive")
+	case m.BoardingTime <= 0 || m.AlightingTime <= 0:
+		return fmt.Errorf("boarding and alighting time have to be positive")
+	case m.CrowdingFriction < 0:
+		return fmt.Errorf("crowding friction can't be negative")
+	}
+
+	return nil
+}
@@ -3,22 +3,108 @@ package tram
 import (
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
 )
 
 func TramsToCSVBuffer(trams map[uint]*Tram, writer io.Writer) error {
-	writer.Write([]byte("tram_id,stop_id,stop_index,time,arrival_time,departure_time\n"))
+	writer.Write([]byte("tram_id,stop_id,stop_index,time,arrival_time,departure_time,is_skipped\n"))
 
-	for _, tram := range trams {
+	for _, tramID := range slices.Sorted(maps.Keys(trams)) {
+		tram := trams[tramID]
 		for stopIndex, stop := range tram.TripDetails.Trip.Stops {
 			_, err := fmt.Fprintf(
 				writer,
-				"%d,%d,%d,%d,%d,%d\n",
+				"%d,%d,%d,%d,%d,%d,%t\n",
 				tram.ID,
 				stop.ID,
 				stopIndex,
 				stop.Time,
 				tram.TripDetails.Arrivals[stopIndex],
 				tram.TripDetails.Departures[stopIndex],
+				tram.TripDetails.Skipped[stopIndex],
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Writes summary of trips done by each vehicle. Layover time is the time spent
+// at terminals between arriving with a trip and departing with the next one.
+func VehiclesToCSVBuffer(blocks []vehicle.Block, trams map[uint]*Tram, writer io.Writer) error {
+	writer.Write([]byte("vehicle_id,vehicle_type,trip_count,first_departure_time,last_arrival_time,layover_time,mileage_km\n"))
+
+	for _, block := range blocks {
+		var firstDepartureTime, lastArrivalTime, layoverTime uint
+		var distanceTravelled float32
+		var previousTrip *Tram
+
+		for _, tripID := range block.TripIDs {
+			tram := trams[tripID]
+			departures, arrivals := tram.TripDetails.Departures, tram.TripDetails.Arrivals
+			distanceTravelled += tram.distanceTravelled
+
+			if firstDepartureTime == 0 {
+				firstDepartureTime = departures[0]
+			}
+
+			if previousTrip != nil && previousTrip.state == StateTripFinished && departures[0] != 0 {
+				layoverTime += departures[0] - previousTrip.TripDetails.Arrivals[len(previousTrip.TripDetails.Arrivals)-1]
+			}
+
+			// trips withdrawn from service end before their last stop
+			if tram.state == StateTripFinished {
+				lastArrivalTime = max(lastArrivalTime, slices.Max(arrivals))
+			}
+
+			previousTrip = tram
+		}
+
+		_, err := fmt.Fprintf(
+			writer,
+			"%d,%s,%d,%d,%d,%d,%.3f\n",
+			block.ID,
+			trams[block.TripIDs[0]].VehicleType.Name,
+			len(block.TripIDs),
+			firstDepartureTime,
+			lastArrivalTime,
+			layoverTime,
+			distanceTravelled/1000,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func IncidentsToCSVBuffer(trams map[uint]*Tram, writer io.Writer) error {
+	writer.Write([]byte("tram_id,vehicle_id,start_time,end_time,node_id,lat,lon,passenger_count,is_withdrawn\n"))
+
+	for _, tramID := range slices.Sorted(maps.Keys(trams)) {
+		tram := trams[tramID]
+		for _, incident := range tram.incidents {
+			_, err := fmt.Fprintf(
+				writer,
+				"%d,%d,%d,%d,%d,%f,%f,%d,%t\n",
+				tram.ID,
+				tram.VehicleID,
+				incident.StartTime,
+				incident.EndTime,
+				incident.NodeID,
+				incident.Lat,
+				incident.Lon,
+				incident.PassengerCount,
+				incident.IsWithdrawn,
 			)
 
 			if err != nil {
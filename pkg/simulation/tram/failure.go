@@ -0,0 +1,93 @@
+package tram
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	REPAIR_TIME_FIXED       = "fixed"
+	REPAIR_TIME_UNIFORM     = "uniform"
+	REPAIR_TIME_EXPONENTIAL = "exponential"
+)
+
+// Decides when running trams break down and how long it takes to repair them.
+// Breakdown probability grows with the distance and time travelled.
+type FailureModel struct {
+	// expected number of breakdowns per kilometer and per hour of running
+	BreakdownsPerKilometer float64 `json:"breakdownsPerKilometer"`
+	BreakdownsPerHour      float64 `json:"breakdownsPerHour"`
+	// distribution of repair time in seconds: fixed (mean), uniform (min to max)
+	// or exponential (min plus exponentially distributed time with the given mean, up to max)
+	RepairTimeDistribution string `json:"repairTimeDistribution"`
+	MinRepairTime          uint   `json:"minRepairTime"`
+	MeanRepairTime         uint   `json:"meanRepairTime"`
+	MaxRepairTime          uint   `json:"maxRepairTime"`
+	// probability of sending the tram to the depot after repair instead of returning to service
+	WithdrawalProbability float64 `json:"withdrawalProbability"`
+}
+
+// Breakdown of a tram and its recovery
+type Incident struct {
+	StartTime uint `json:"startTime"`
+	// time of the tram being repaired
+	EndTime        uint    `json:"endTime"`
+	NodeID         uint64  `json:"nodeID"`
+	Lat            float32 `json:"lat"`
+	Lon            float32 `json:"lon"`
+	PassengerCount uint    `json:"passengerCount"`
+	IsWithdrawn    bool    `json:"isWithdrawn"`
+}
+
+func (m *FailureModel) Validate() error {
+	switch {
+	case m.BreakdownsPerKilometer < 0 || m.BreakdownsPerHour < 0:
+		return fmt.Errorf("breakdown rates can't be negative")
+	case m.WithdrawalProbability < 0 || m.WithdrawalProbability > 1:
+		return fmt.Errorf("withdrawal probability has to be between 0 and 1")
+	}
+
+	switch m.RepairTimeDistribution {
+	case REPAIR_TIME_FIXED:
+		if m.MeanRepairTime == 0 {
+			return fmt.Errorf("mean repair time has to be positive")
+		}
+	case REPAIR_TIME_UNIFORM:
+		if m.MinRepairTime > m.MaxRepairTime || m.MaxRepairTime == 0 {
+			return fmt.Errorf("max repair time has to be positive and not less than min repair time")
+		}
+	case REPAIR_TIME_EXPONENTIAL:
+		if m.MeanRepairTime <= m.MinRepairTime || m.MaxRepairTime != 0 && m.MaxRepairTime < m.MeanRepairTime {
+			return fmt.Errorf("mean repair time has to be greater than min repair time and not greater than max repair time")
+		}
+	default:
+		return fmt.Errorf("unknown repair time distribution %q", m.RepairTimeDistribution)
+	}
+
+	return nil
+}
+
+func (m *FailureModel) isEnabled() bool {
+	return m != nil && (m.BreakdownsPerKilometer > 0 || m.BreakdownsPerHour > 0)
+}
+
+// Returns true if the tram breaks down after travelling the given distance in meters during one second
+func (m *FailureModel) isBreakingDown(distance float32, random *rand.Rand) bool {
+	probability := m.BreakdownsPerKilometer*float64(distance)/1000 + m.BreakdownsPerHour/3600
+	return random.Float64() < probability
+}
+
+func (m *FailureModel) getRepairTime(random *rand.Rand) uint {
+	switch m.RepairTimeDistribution {
+	case REPAIR_TIME_UNIFORM:
+		return m.MinRepairTime + uint(random.UintN(m.MaxRepairTime-m.MinRepairTime+1))
+	case REPAIR_TIME_EXPONENTIAL:
+		repairTime := m.MinRepairTime + uint(random.ExpFloat64()*float64(m.MeanRepairTime-m.MinRepairTime))
+		if m.MaxRepairTime != 0 {
+			repairTime = min(repairTime, m.MaxRepairTime)
+		}
+		return repairTime
+	default:
+		return m.MeanRepairTime
+	}
+}
@@ -0,0 +1,48 @@
+package tram
+
+import "github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+
+// Trams of the same route stopping at a stop before and after the tram
+type headwayNeighbors struct {
+	previous, next                   *Tram
+	previousStopIndex, nextStopIndex int
+}
+
+// Links the tram with the previous tram of the same route at the stop with the given index
+func (t *Tram) LinkPreviousTramAtStop(stopIndex int, previous *Tram, previousStopIndex int) {
+	t.headwayNeighbors[stopIndex].previous = previous
+	t.headwayNeighbors[stopIndex].previousStopIndex = previousStopIndex
+	previous.headwayNeighbors[previousStopIndex].next = t
+	previous.headwayNeighbors[previousStopIndex].nextStopIndex = stopIndex
+}
+
+// Decides how long the tram waiting at a timing stop is held on headway.
+// Called for all trams before any of them advances, as it depends on other trams.
+func (t *Tram) PlanHolding(time uint) {
+	t.holdingTime = 0
+
+	if t.state != StatePassengersLoading {
+		return
+	}
+
+	stopIndex := t.TripDetails.Index
+	stop := t.TripDetails.Trip.Stops[stopIndex]
+	if !t.controlCenter.IsHeldOnHeadway(t.Route.Name, stop.ID) {
+		return
+	}
+
+	neighbors := t.headwayNeighbors[stopIndex]
+	headways := controlcenter.Headways{ArrivalTime: t.TripDetails.Arrivals[stopIndex]}
+
+	if previous := neighbors.previous; previous != nil {
+		headways.ScheduledHeadway = stop.Time - previous.TripDetails.Trip.Stops[neighbors.previousStopIndex].Time
+		headways.PreviousDeparture = previous.TripDetails.Departures[neighbors.previousStopIndex]
+	}
+
+	if next := neighbors.next; next != nil && next.state != StateTripFinished {
+		// next arrival stays unknown if the next tram skips the stop
+		headways.NextArrival, _ = next.GetEstimatedArrival(neighbors.nextStopIndex, time)
+	}
+
+	t.holdingTime = t.controlCenter.GetHoldingTime(t.Route.Name, stop.ID, headways)
+}
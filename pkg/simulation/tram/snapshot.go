@@ -0,0 +1,208 @@
+package tram
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+)
+
+type TramSnapshot struct {
+	ID                  uint       `json:"id"`
+	PathIndex           int        `json:"pathIndex"`
+	Speed               float32    `json:"speed"`
+	Lat                 float32    `json:"lat"`
+	Lon                 float32    `json:"lon"`
+	Azimuth             float32    `json:"azimuth"`
+	DistToNextInterNode float32    `json:"distToNextInterNode"`
+	TripIndex           int        `json:"tripIndex"`
+	Arrivals            []uint     `json:"arrivals"`
+	Departures          []uint     `json:"departures"`
+	BlockedNodesBehind  []uint64   `json:"blockedNodesBehind"`
+	DetourNodeIDs       []uint64   `json:"detourNodeIDs,omitempty"`
+	SkippedStops        []bool     `json:"skippedStops,omitempty"`
+	DepartureTime       uint       `json:"departureTime"`
+	IsFinished          bool       `json:"isFinished"`
+	State               TramState  `json:"state"`
+	PrevState           TramState  `json:"prevState"`
+	PassengerIDs        []uint64   `json:"passengerIDs"`
+	PassengerChangeTime float32    `json:"passengerChangeTime"`
+	DistanceTravelled   float32    `json:"distanceTravelled,omitempty"`
+	IsPullingOut        bool       `json:"isPullingOut,omitempty"`
+	IsPullingIn         bool       `json:"isPullingIn,omitempty"`
+	HasPulledOut        bool       `json:"hasPulledOut,omitempty"`
+	HasPulledIn         bool       `json:"hasPulledIn,omitempty"`
+	PullOutTime         uint       `json:"pullOutTime,omitempty"`
+	PullInTime          uint       `json:"pullInTime,omitempty"`
+	Incidents           []Incident `json:"incidents,omitempty"`
+	RepairTime          uint       `json:"repairTime,omitempty"`
+	IsWithdrawn         bool       `json:"isWithdrawn,omitempty"`
+	IsCancelled         bool       `json:"isCancelled,omitempty"`
+	RandomState         []byte     `json:"randomState"`
+}
+
+func (t *Tram) GetSnapshot() TramSnapshot {
+	blockedNodesBehind := make([]uint64, 0, len(t.blockedNodesBehind))
+	for _, node := range t.blockedNodesBehind {
+		blockedNodesBehind = append(blockedNodesBehind, node.GetID())
+	}
+
+	var detourNodeIDs []uint64
+	if t.detour != nil {
+		for _, node := range t.detour.Nodes {
+			detourNodeIDs = append(detourNodeIDs, node.GetID())
+		}
+	}
+
+	var skippedStops []bool
+	if slices.Contains(t.TripDetails.Skipped, true) {
+		skippedStops = slices.Clone(t.TripDetails.Skipped)
+	}
+
+	randomState, err := t.randomSource.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	return TramSnapshot{
+		ID:                  t.ID,
+		PathIndex:           t.pathIndex,
+		Speed:               t.speed,
+		Lat:                 t.lat,
+		Lon:                 t.lon,
+		Azimuth:             t.azimuth,
+		DistToNextInterNode: t.distToNextInterNode,
+		TripIndex:           t.TripDetails.Index,
+		Arrivals:            slices.Clone(t.TripDetails.Arrivals),
+		Departures:          slices.Clone(t.TripDetails.Departures),
+		BlockedNodesBehind:  blockedNodesBehind,
+		DetourNodeIDs:       detourNodeIDs,
+		SkippedStops:        skippedStops,
+		DepartureTime:       t.departureTime,
+		IsFinished:          t.isFinished,
+		State:               t.state,
+		PrevState:           t.prevState,
+		PassengerIDs:        slices.Sorted(maps.Keys(t.passengersInTram)),
+		PassengerChangeTime: t.passengerChangeTime,
+		DistanceTravelled:   t.distanceTravelled,
+		IsPullingOut:        t.isPullingOut,
+		IsPullingIn:         t.isPullingIn,
+		HasPulledOut:        t.hasPulledOut,
+		HasPulledIn:         t.hasPulledIn,
+		PullOutTime:         t.pullOutTime,
+		PullInTime:          t.pullInTime,
+		Incidents:           slices.Clone(t.incidents),
+		RepairTime:          t.repairTime,
+		IsWithdrawn:         t.isWithdrawn,
+		IsCancelled:         t.isCancelled,
+		RandomState:         randomState,
+	}
+}
+
+// Checks if the snapshot can be restored to the tram
+func (t *Tram) ValidateSnapshot(
+	snapshot TramSnapshot,
+	nodesByID map[uint64]graph.GraphNode,
+	passengersStore *passenger.PassengersStore,
+) error {
+	if snapshot.ID != t.ID {
+		return fmt.Errorf("snapshot of tram %d can't be restored to tram %d", snapshot.ID, t.ID)
+	}
+
+	stopCount := len(t.TripDetails.Trip.Stops)
+	if len(snapshot.Arrivals) != stopCount || len(snapshot.Departures) != stopCount {
+		return fmt.Errorf("tram %d: expected %d stops in snapshot", t.ID, stopCount)
+	}
+
+	if snapshot.SkippedStops != nil && len(snapshot.SkippedStops) != stopCount {
+		return fmt.Errorf("tram %d: expected %d stops in snapshot", t.ID, stopCount)
+	}
+
+	for _, nodeID := range snapshot.BlockedNodesBehind {
+		if _, ok := nodesByID[nodeID]; !ok {
+			return fmt.Errorf("tram %d: node %d not found", t.ID, nodeID)
+		}
+	}
+
+	if snapshot.DetourNodeIDs != nil {
+		if _, err := t.controlCenter.GetPathFromNodeIDs(snapshot.DetourNodeIDs); err != nil {
+			return fmt.Errorf("tram %d: %w", t.ID, err)
+		}
+	}
+
+	for _, passengerID := range snapshot.PassengerIDs {
+		if passengersStore.GetPassenger(passengerID) == nil {
+			return fmt.Errorf("tram %d: passenger %d not found", t.ID, passengerID)
+		}
+	}
+
+	if err := new(rand.PCG).UnmarshalBinary(snapshot.RandomState); err != nil {
+		return fmt.Errorf("tram %d: %w", t.ID, err)
+	}
+
+	return nil
+}
+
+// Restores the state of the tram from the snapshot, which is validated first
+func (t *Tram) RestoreSnapshot(
+	snapshot TramSnapshot,
+	nodesByID map[uint64]graph.GraphNode,
+	passengersStore *passenger.PassengersStore,
+) error {
+	if err := t.ValidateSnapshot(snapshot, nodesByID, passengersStore); err != nil {
+		return err
+	}
+
+	blockedNodesBehind := make([]graph.GraphNode, 0, len(snapshot.BlockedNodesBehind))
+	for _, nodeID := range snapshot.BlockedNodesBehind {
+		blockedNodesBehind = append(blockedNodesBehind, nodesByID[nodeID])
+	}
+
+	var detour *controlcenter.Path
+	if snapshot.DetourNodeIDs != nil {
+		detour, _ = t.controlCenter.GetPathFromNodeIDs(snapshot.DetourNodeIDs)
+	}
+
+	skippedStops := make([]bool, len(t.TripDetails.Trip.Stops))
+	copy(skippedStops, snapshot.SkippedStops)
+
+	passengersInTram := make(map[uint64]*passenger.Passenger, len(snapshot.PassengerIDs))
+	for _, passengerID := range snapshot.PassengerIDs {
+		passengersInTram[passengerID] = passengersStore.GetPassenger(passengerID)
+	}
+
+	if err := t.randomSource.UnmarshalBinary(snapshot.RandomState); err != nil {
+		return fmt.Errorf("tram %d: %w", t.ID, err)
+	}
+
+	t.pathIndex = snapshot.PathIndex
+	t.speed = snapshot.Speed
+	t.lat, t.lon, t.azimuth = snapshot.Lat, snapshot.Lon, snapshot.Azimuth
+	t.distToNextInterNode = snapshot.DistToNextInterNode
+	t.TripDetails.Index = snapshot.TripIndex
+	copy(t.TripDetails.Arrivals, snapshot.Arrivals)
+	copy(t.TripDetails.Departures, snapshot.Departures)
+	t.TripDetails.Skipped = skippedStops
+	t.blockedNodesBehind = blockedNodesBehind
+	t.detour = detour
+	t.departureTime = snapshot.DepartureTime
+	t.isFinished = snapshot.IsFinished
+	t.state = snapshot.State
+	t.prevState = snapshot.PrevState
+	t.passengersInTram = passengersInTram
+	t.passengerChangeTime = snapshot.PassengerChangeTime
+	t.distanceTravelled = snapshot.DistanceTravelled
+	t.isPullingOut, t.isPullingIn = snapshot.IsPullingOut, snapshot.IsPullingIn
+	t.hasPulledOut, t.hasPulledIn = snapshot.HasPulledOut, snapshot.HasPulledIn
+	t.pullOutTime, t.pullInTime = snapshot.PullOutTime, snapshot.PullInTime
+	t.incidents = slices.Clone(snapshot.Incidents)
+	t.repairTime = snapshot.RepairTime
+	t.isWithdrawn, t.isCancelled = snapshot.IsWithdrawn, snapshot.IsCancelled
+	t.passengersStore = passengersStore
+
+	return nil
+}
@@ -10,26 +10,52 @@ import (
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
 )
 
-const MAX_ACCELERATION = 1.0
-
 type Tram struct {
 	ID                  uint
 	pathIndex           int
-	speed, length       float32
+	speed               float32
 	lat, lon, azimuth   float32
 	distToNextInterNode float32
 	Route               *trip.TramRoute
+	VehicleType         *vehicle.VehicleType
 	TripDetails         tripDetails
 	controlCenter       *controlcenter.ControlCenter
 	blockedNodesBehind  []graph.GraphNode
+	detour              *controlcenter.Path
 	departureTime       uint
 	isFinished          bool
 	state               TramState
 	prevState           TramState
 	passengersInTram    map[uint64]*passenger.Passenger
 	passengersStore     *passenger.PassengersStore
+	dwellTimeModel      DwellTimeModel
+	failureModel        *FailureModel
+	incidents           []Incident
+	repairTime          uint
+	isWithdrawn         bool
+	isCancelled         bool
+	headwayNeighbors    []headwayNeighbors
+	holdingTime         uint
+	passengerChangeTime float32
+	distanceTravelled   float32
+	VehicleID           uint
+	previousTrip        *Tram
+	previousTripEndTime uint
+	depotID             uint
+	depotNodeID         uint64
+	pullOutPath         *controlcenter.Path
+	pullInPath          *controlcenter.Path
+	isPullingOut        bool
+	isPullingIn         bool
+	hasPulledOut        bool
+	hasPulledIn         bool
+	pullOutTime         uint
+	pullInTime          uint
+	randomSource        *rand.PCG
+	random              *rand.Rand
 }
 
 func NewTram(
@@ -38,18 +64,28 @@ func NewTram(
 	trip *trip.TramTrip,
 	controlCenter *controlcenter.ControlCenter,
 	passengersStore *passenger.PassengersStore,
+	vehicleType *vehicle.VehicleType,
+	dwellTimeModel DwellTimeModel,
+	failureModel *FailureModel,
+	randomSource *rand.PCG,
 ) *Tram {
+	random := rand.New(randomSource)
 	startTime := uint(trip.Stops[0].Time)
 	return &Tram{
 		ID:               id,
-		length:           30,
 		Route:            route,
+		VehicleType:      vehicleType,
 		TripDetails:      newTripDetails(trip),
-		departureTime:    startTime - uint(rand.IntN(11)) - 15,
+		departureTime:    startTime - uint(random.IntN(11)) - 15,
 		state:            StateTripNotStarted,
 		controlCenter:    controlCenter,
 		passengersStore:  passengersStore,
+		dwellTimeModel:   dwellTimeModel,
+		failureModel:     failureModel,
+		headwayNeighbors: make([]headwayNeighbors, len(trip.Stops)),
 		passengersInTram: make(map[uint64]*passenger.Passenger),
+		randomSource:     randomSource,
+		random:           random,
 	}
 }
 
@@ -74,12 +110,34 @@ func (t *Tram) Advance(time uint, stopsByID map[uint64]*graph.GraphTramStop) (re
 		result, update = t.onTravelling(time)
 	case StateTripFinished:
 		result, update = t.onTripFinished()
+	case StateBrokenDown:
+		result, update = t.onBrokenDown(time)
 	}
 
 	result.Delay = t.TripDetails.getDelay(time)
 	return
 }
 
+// Returns current position of the tram, if the tram is visible on the map
+func (t *Tram) GetPositionChange(time uint) (TramPositionChange, bool) {
+	if t.state == StateTripNotStarted || t.isFinished {
+		return TramPositionChange{}, false
+	}
+
+	return TramPositionChange{
+		TramID:  t.ID,
+		Lat:     t.lat,
+		Lon:     t.lon,
+		Azimuth: t.azimuth,
+		State:   t.state,
+		Delay:   t.TripDetails.getDelay(time),
+	}, true
+}
+
+func (t *Tram) IsFinished() bool {
+	return t.isFinished
+}
+
 func (t *Tram) IsAtStop() bool {
 	if t.state == StateStopped {
 		return t.prevState == StatePassengersLoading || t.prevState == StatePassengersUnloading
@@ -88,10 +146,81 @@ func (t *Tram) IsAtStop() bool {
 	return t.state == StatePassengersLoading || t.state == StatePassengersUnloading
 }
 
+// Assigns the tram to a vehicle, which does the trip after finishing the previous trip of its block
+func (t *Tram) SetVehicle(vehicleID uint, previousTrip *Tram) {
+	t.VehicleID = vehicleID
+	t.previousTrip = previousTrip
+}
+
+// Checks if the vehicle finished the previous trip of its block.
+// Called for all trams before any of them advances, like claiming nodes.
+func (t *Tram) CheckPreviousTrip() {
+	if t.state != StateTripNotStarted || t.previousTrip == nil || t.previousTripEndTime != 0 || t.isCancelled {
+		return
+	}
+
+	if previous := t.previousTrip; previous.state == StateTripFinished {
+		t.isCancelled = previous.isWithdrawn || previous.isCancelled
+		t.previousTripEndTime = previous.TripDetails.Departures[len(previous.TripDetails.Departures)-1]
+	}
+}
+
+// Assigns the tram to the depot, which it goes to when withdrawn from service
+func (t *Tram) SetDepot(depotID uint, nodeID uint64) {
+	t.depotID = depotID
+	t.depotNodeID = nodeID
+}
+
+// Makes the tram pull out from the depot at the given time and run without passengers
+// to the first stop of its trip
+func (t *Tram) SetPullOut(path *controlcenter.Path, time uint) {
+	t.pullOutPath = path
+	t.departureTime = time
+}
+
+// Makes the tram run without passengers to the depot after finishing its trip
+func (t *Tram) SetPullIn(path *controlcenter.Path) {
+	t.pullInPath = path
+}
+
+// Returns times of the tram leaving and entering its depot, if it did so already
+func (t *Tram) GetDepotMovements() (depotID uint, movements []vehicle.DepotMovement) {
+	if t.hasPulledOut {
+		movements = append(movements, vehicle.DepotMovement{Time: t.pullOutTime})
+	}
+
+	if t.hasPulledIn {
+		movements = append(movements, vehicle.DepotMovement{Time: t.pullInTime, IsPullIn: true})
+	}
+
+	return t.depotID, movements
+}
+
+// Returns true if the tram runs between the depot and a terminal without passengers
+func (t *Tram) IsDeadRunning() bool {
+	return t.isPullingOut || t.isPullingIn
+}
+
+func (t *Tram) IsPullingIn() bool {
+	return t.isPullingIn
+}
+
+func (t *Tram) GetIncidents() []Incident {
+	return t.incidents
+}
+
+func (t *Tram) GetDistanceTravelled() float32 {
+	return t.distanceTravelled
+}
+
 func (t *Tram) getTravelPath() *controlcenter.Path {
+	if t.detour != nil {
+		return t.detour
+	}
+
 	startStopID, endStopID := 0, 1
 	if t.TripDetails.Index > 0 {
-		startStopID, endStopID = t.TripDetails.Index-1, t.TripDetails.Index
+		startStopID, endStopID = t.TripDetails.getPreviousIndex(), t.TripDetails.Index
 	}
 
 	previousStop := t.TripDetails.Trip.Stops[startStopID]
@@ -108,6 +237,7 @@ func (t *Tram) findNewLocation(path []graph.GraphNode, distanceToDrive float32)
 
 		if t.distToNextInterNode <= distanceToDrive {
 			distanceToDrive -= t.distToNextInterNode
+			t.distanceTravelled += t.distToNextInterNode
 			t.pathIndex++
 			t.blockedNodesBehind = append(t.blockedNodesBehind, path[t.pathIndex])
 			t.distToNextInterNode = 0
@@ -115,6 +245,7 @@ func (t *Tram) findNewLocation(path []graph.GraphNode, distanceToDrive float32)
 		} else {
 			remainingPart := distanceToDrive / t.distToNextInterNode
 			t.distToNextInterNode -= distanceToDrive
+			t.distanceTravelled += distanceToDrive
 			t.findIntermediateLocation(path, remainingPart)
 			distanceToDrive = 0
 		}
@@ -157,7 +288,63 @@ func (t *Tram) nextNodeDistance(path []graph.GraphNode, i int) float32 {
 	return t.getDistanceToNeighbor(path[i], path[i+1])
 }
 
-func (t *Tram) blockNodesBehind() {
+func isNextNodeClosed(path []graph.GraphNode, i int) bool {
+	return path[i+1].IsClosed() || path[i].IsEdgeClosed(path[i+1].GetID())
+}
+
+// Returns index of the next stop and a detour leading to it, if the regular path is closed.
+// Returns false if the tram has to wait at the current stop until the path is opened.
+func (t *Tram) planPathToNextStop() (int, *controlcenter.Path, bool) {
+	index := t.TripDetails.Index
+	stops := t.TripDetails.Trip.Stops
+
+	path := t.controlCenter.GetPath(stops[index].ID, stops[index+1].ID)
+	if !t.controlCenter.HasActiveClosures() || !path.IsClosedAfter(0) {
+		return index + 1, nil, true
+	}
+
+	startNodes := path.Nodes[:1]
+	if detour, i, ok := t.controlCenter.GetDetour(startNodes, t.TripDetails.getStopIDsFrom(index+1)); ok {
+		return index + 1 + i, detour, true
+	}
+
+	return index + 1, nil, !t.controlCenter.IsHeldAtStop(stops[index].ID, stops[index+1].ID)
+}
+
+// Swaps the travel path for a detour, if the track ahead of the tram is closed.
+// Stops which can't be reached are skipped.
+func (t *Tram) rerouteAroundClosedTrack() {
+	path := t.getTravelPath()
+	if !t.controlCenter.HasActiveClosures() || !path.IsClosedAfter(t.pathIndex) {
+		return
+	}
+
+	startNodes := path.Nodes[t.pathIndex : t.pathIndex+1]
+	if t.distToNextInterNode > 0 {
+		// the tram can't turn back before reaching the next node
+		if isNextNodeClosed(path.Nodes, t.pathIndex) {
+			return
+		}
+		startNodes = path.Nodes[t.pathIndex : t.pathIndex+2]
+	}
+
+	destinationIDs := t.TripDetails.getStopIDsFrom(t.TripDetails.Index)
+	if t.isPullingIn {
+		destinationIDs = []uint64{t.pullInPath.Nodes[len(t.pullInPath.Nodes)-1].GetID()}
+	}
+
+	detour, i, ok := t.controlCenter.GetDetour(startNodes, destinationIDs)
+	if !ok {
+		return
+	}
+
+	t.unblockNodesAhead()
+	t.TripDetails.skipStopsUntil(t.TripDetails.Index + i)
+	t.detour = detour
+	t.pathIndex = 0
+}
+
+func (t *Tram) blockNodesBehind(time uint) {
 	if len(t.blockedNodesBehind) == 0 {
 		return
 	}
@@ -165,15 +352,15 @@ func (t *Tram) blockNodesBehind() {
 
 	// block current position of a tram marker
 	u := t.blockedNodesBehind[idx]
-	u.TryBlocking(t.ID)
+	u.TryBlocking(t.ID, time)
 	idx--
 
 	// block nodes behind a tram marker simulating tram length
-	distanceLeft := t.length
+	distanceLeft := t.VehicleType.Length
 	for distanceLeft > 0 && idx >= 0 {
 		v := t.blockedNodesBehind[idx]
 		distanceLeft -= t.getDistanceToNeighbor(v, u)
-		v.TryBlocking(t.ID)
+		v.TryBlocking(t.ID, time)
 		u = v
 		idx--
 	}
@@ -200,28 +387,89 @@ func (t *Tram) unblockNodesAhead() {
 	}
 }
 
-func (t *Tram) GetEstimatedArrival(stopIndex int, time uint) uint {
-	if t.TripDetails.Index > stopIndex || t.TripDetails.Index == stopIndex && t.IsAtStop() {
-		return t.TripDetails.Arrivals[stopIndex]
+// Returns time of arrival at the stop, false if the tram hasn't arrived there yet or skipped the stop
+func (t *Tram) GetActualArrival(stopIndex int) (uint, bool) {
+	if t.TripDetails.Skipped[stopIndex] {
+		return 0, false
+	}
+
+	isArrived := t.TripDetails.Index > stopIndex || t.TripDetails.Index == stopIndex &&
+		(t.IsAtStop() || t.isPullingIn || t.state == StateTripFinished)
+
+	return t.TripDetails.Arrivals[stopIndex], isArrived
+}
+
+// Returns time of departure from the stop, false if the tram hasn't departed from there yet or skipped the stop
+func (t *Tram) GetActualDeparture(stopIndex int) (uint, bool) {
+	if t.TripDetails.Skipped[stopIndex] {
+		return 0, false
+	}
+
+	isDeparted := t.TripDetails.Index > stopIndex || t.TripDetails.Index == stopIndex &&
+		(t.isPullingIn || t.state == StateTripFinished)
+
+	return t.TripDetails.Departures[stopIndex], isDeparted
+}
+
+// Returns estimated departure from the first stop. Trips of a block start after the vehicle
+// finished the previous trip and the layover, but not before their scheduled time.
+// Returns false if the trip is going to be cancelled.
+func (t *Tram) getEstimatedStartTime(time uint) (uint, bool) {
+	startTime := t.TripDetails.Trip.Stops[0].Time
+	if t.state != StateTripNotStarted || t.previousTrip == nil {
+		return startTime, true
+	}
+
+	previous := t.previousTrip
+	if t.isCancelled || previous.isWithdrawn || previous.isCancelled {
+		return 0, false
+	}
+
+	previousTripEndTime := t.previousTripEndTime
+	if previousTripEndTime == 0 {
+		lastStopIndex := len(previous.TripDetails.Trip.Stops) - 1
+
+		var ok bool
+		if previousTripEndTime, ok = previous.GetEstimatedArrival(lastStopIndex, time); !ok {
+			previousTripEndTime = previous.TripDetails.Trip.Stops[lastStopIndex].Time
+		}
+	}
+
+	return max(startTime, previousTripEndTime+vehicle.MIN_LAYOVER_TIME), true
+}
+
+// Returns actual or estimated time of arrival at the stop,
+// false if the tram skips the stop or its trip is going to be cancelled
+func (t *Tram) GetEstimatedArrival(stopIndex int, time uint) (uint, bool) {
+	if t.TripDetails.Skipped[stopIndex] {
+		return 0, false
+	}
+
+	if t.TripDetails.Index > stopIndex || t.TripDetails.Index == stopIndex && (t.IsAtStop() || t.isPullingIn) {
+		return t.TripDetails.Arrivals[stopIndex], true
 	}
 
 	pathProgress := t.getTravelPath().GetProgressForIndex(t.pathIndex)
 
 	if t.TripDetails.Index == 0 || stopIndex == 0 {
-		lastDeparture := t.TripDetails.Trip.Stops[0].Time
+		lastDeparture, ok := t.getEstimatedStartTime(time)
+		if !ok {
+			return 0, false
+		}
+
 		scheduledTravelTime := t.TripDetails.Trip.GetScheduledTravelTime(0, stopIndex)
-		return lastDeparture + scheduledTravelTime
+		return lastDeparture + scheduledTravelTime, true
 	}
 
 	pathLeft := float64(1 - pathProgress)
-	scheduledTravelTimeToNextStop := t.TripDetails.Trip.GetScheduledTravelTime(t.TripDetails.Index-1, t.TripDetails.Index)
+	scheduledTravelTimeToNextStop := t.TripDetails.Trip.GetScheduledTravelTime(t.TripDetails.getPreviousIndex(), t.TripDetails.Index)
 
 	remainingTravelTimeToNextStop := uint(math.Round(float64(scheduledTravelTimeToNextStop) * pathLeft))
 	estimatedArrivalAtNextStop := time + remainingTravelTimeToNextStop
 
 	// Estimating arrival at next tram stop
 	if t.TripDetails.Index == stopIndex {
-		return estimatedArrivalAtNextStop
+		return estimatedArrivalAtNextStop, true
 	}
 
 	var estimatedPositiveDelay uint
@@ -229,7 +477,7 @@ func (t *Tram) GetEstimatedArrival(stopIndex int, time uint) uint {
 		estimatedPositiveDelay = estimatedArrivalAtNextStop - t.TripDetails.Trip.Stops[t.TripDetails.Index].Time
 	}
 
-	return t.TripDetails.Trip.Stops[stopIndex].Time + estimatedPositiveDelay
+	return t.TripDetails.Trip.Stops[stopIndex].Time + estimatedPositiveDelay, true
 }
 
 // Guarantees smooth arrival and deceleration to another tram, stop or a section
@@ -238,15 +486,16 @@ func (t *Tram) GetEstimatedArrival(stopIndex int, time uint) uint {
 func (t *Tram) handleDeceleration(targetDistance, targetSpeed, maxSpeed float32) float32 {
 	// (v0+v1target)/2 + v1target^2/(2a) = targetDistance =>
 	// v1target^2 + v1target*a + v0*a - 2*a*targetDistance = 0
+	deceleration := t.VehicleType.MaxDeceleration
 	A := 1.0
-	B := float64(MAX_ACCELERATION)
-	C := float64(MAX_ACCELERATION * (t.speed - 2*targetDistance))
+	B := float64(deceleration)
+	C := float64(deceleration * (t.speed - 2*targetDistance))
 	// sometimes delta < 0 due to numerical errors
 	delta := max(0, B*B-4*A*C)
 	v1target := float32((-B + math.Sqrt(delta)) / (2 * A))
 
-	v1min := max(t.speed-MAX_ACCELERATION, targetSpeed) // do not go below target speed
-	v1max := min(t.speed+MAX_ACCELERATION, maxSpeed)    // do not exceed max speed
+	v1min := max(t.speed-deceleration, targetSpeed)               // do not go below target speed
+	v1max := min(t.speed+t.VehicleType.MaxAcceleration, maxSpeed) // do not exceed max speed
 
 	if v1target < v1min {
 		return v1min
@@ -258,7 +507,16 @@ func (t *Tram) handleDeceleration(targetDistance, targetSpeed, maxSpeed float32)
 }
 
 func (t *Tram) getBlockingDistance(speed float32) float32 {
-	return speed + speed*speed/(2*MAX_ACCELERATION) + 2*t.length
+	return speed + speed*speed/(2*t.VehicleType.MaxDeceleration) + 2*t.VehicleType.Length
+}
+
+// Returns max speed at the given index of the path, limited by max speed of the vehicle
+func (t *Tram) getMaxSpeed(path *controlcenter.Path, index int) float32 {
+	if t.VehicleType.MaxSpeed == 0 {
+		return path.MaxSpeeds[index]
+	}
+
+	return min(path.MaxSpeeds[index], t.VehicleType.MaxSpeed)
 }
 
 func (t *Tram) extendReservedDistance(reservedDistance, neededDistance, distanceToNextNode float32) float32 {
@@ -270,9 +528,32 @@ func (t *Tram) extendReservedDistance(reservedDistance, neededDistance, distance
 	return reservedDistance
 }
 
-func (t *Tram) updateSpeedAndReserveNodes(path *controlcenter.Path) (availableDistance float32) {
-	currentMaxSpeed := path.MaxSpeeds[t.pathIndex]
-	newSpeed := min(t.speed+MAX_ACCELERATION, currentMaxSpeed)
+// Claims nodes which will be reserved by the tram when advancing at the given time.
+// Claiming is done for all trams before any of them advances.
+func (t *Tram) ClaimNodesAhead(time uint) {
+	if t.state != StateTravelling && t.state != StateStopping {
+		return
+	}
+
+	path := t.getTravelPath()
+	newSpeed := min(t.speed+t.VehicleType.MaxAcceleration, t.getMaxSpeed(path, t.pathIndex))
+	neededReserve := t.getBlockingDistance(newSpeed)
+
+	// claim nodes ahead the same way as they are reserved in updateSpeedAndReserveNodes
+	var reservedDistance float32
+	for i := t.pathIndex; i < len(path.Nodes)-1 && reservedDistance < neededReserve; i++ {
+		u := path.Nodes[i+1]
+		if isNextNodeClosed(path.Nodes, i) || !u.Claim(t.ID, time) || u.IsTramStop() {
+			break
+		}
+
+		reservedDistance = t.extendReservedDistance(reservedDistance, neededReserve, t.nextNodeDistance(path.Nodes, i))
+	}
+}
+
+func (t *Tram) updateSpeedAndReserveNodes(path *controlcenter.Path, time uint) (availableDistance float32) {
+	currentMaxSpeed := t.getMaxSpeed(path, t.pathIndex)
+	newSpeed := min(t.speed+t.VehicleType.MaxAcceleration, currentMaxSpeed)
 
 	neededReserveAtCurrentSpeed := t.getBlockingDistance(t.speed)
 	neededReserveIfAccel := t.getBlockingDistance(newSpeed)
@@ -289,12 +570,12 @@ func (t *Tram) updateSpeedAndReserveNodes(path *controlcenter.Path) (availableDi
 		distToNextNode := t.nextNodeDistance(path.Nodes, i)
 
 		// set distance to upcoming speed limit change (if the speed limit is lower)
-		if path.MaxSpeeds[i+1] < currentMaxSpeed && distToMaxSpeedChange == 0 {
-			upcomingMaxSpeed = path.MaxSpeeds[i+1]
+		if t.getMaxSpeed(path, i+1) < currentMaxSpeed && distToMaxSpeedChange == 0 {
+			upcomingMaxSpeed = t.getMaxSpeed(path, i+1)
 			distToMaxSpeedChange = reservedDistanceAhead
 		}
 
-		if !u.TryBlocking(t.ID) {
+		if isNextNodeClosed(path.Nodes, i) || !u.TryBlocking(t.ID, time) {
 			distToStop = 1e-3
 			for _, blockedNode := range optimisticallyBlockedNodes {
 				blockedNode.Unblock(t.ID)
@@ -303,7 +584,8 @@ func (t *Tram) updateSpeedAndReserveNodes(path *controlcenter.Path) (availableDi
 			break
 		}
 
-		if u.IsTramStop() {
+		// trams stop at the end of the path, which isn't a stop when pulling in to the depot
+		if u.IsTramStop() || i+1 == len(path.Nodes)-1 {
 			reservedDistanceAhead += distToNextNode
 			distToStop = reservedDistanceAhead
 
@@ -333,7 +615,7 @@ func (t *Tram) updateSpeedAndReserveNodes(path *controlcenter.Path) (availableDi
 			distToNextNode,
 		)
 		//in case we need to stop immediately after detecting blocked node we have to free optimistically blocked nodes
-		if reservedDistanceAhead > neededReserveAtCurrentSpeed-2*t.length {
+		if reservedDistanceAhead > neededReserveAtCurrentSpeed-2*t.VehicleType.Length {
 			optimisticallyBlockedNodes = append(optimisticallyBlockedNodes, u)
 		}
 	}
@@ -372,11 +654,15 @@ func (t *Tram) getSpeed() uint8 {
 
 type TramDetails struct {
 	Route           string                     `json:"route"`
+	VehicleType     string                     `json:"vehicle_type"`
+	VehicleID       uint                       `json:"vehicle_id"`
+	IsDeadRunning   bool                       `json:"is_dead_running"`
 	TripHeadSign    string                     `json:"trip_head_sign"`
 	TripIndex       int                        `json:"trip_index"`
 	Stops           []api.ResponseTramTripStop `json:"stops"`
 	Arrivals        []uint                     `json:"arrivals"`
 	Departures      []uint                     `json:"departures"`
+	Skipped         []bool                     `json:"skipped"`
 	StopNames       []string                   `json:"stop_names"`
 	Speed           uint8                      `json:"speed"`
 	State           TramState                  `json:"state"`
@@ -392,16 +678,22 @@ func (t *Tram) GetDetails(c *city.City, time uint) TramDetails {
 	}
 
 	if t.state != StateTripFinished && t.TripDetails.Index < len(t.TripDetails.Arrivals) {
-		t.TripDetails.Arrivals[t.TripDetails.Index] = t.GetEstimatedArrival(t.TripDetails.Index, time)
+		if arrival, ok := t.GetEstimatedArrival(t.TripDetails.Index, time); ok {
+			t.TripDetails.Arrivals[t.TripDetails.Index] = arrival
+		}
 	}
 
 	return TramDetails{
 		Route:           t.Route.Name,
+		VehicleType:     t.VehicleType.Name,
+		VehicleID:       t.VehicleID,
+		IsDeadRunning:   t.IsDeadRunning(),
 		TripHeadSign:    t.TripDetails.Trip.TripHeadSign,
 		TripIndex:       t.TripDetails.Index,
 		Stops:           t.TripDetails.Trip.Stops,
 		Arrivals:        t.TripDetails.Arrivals,
 		Departures:      t.TripDetails.Departures,
+		Skipped:         t.TripDetails.Skipped,
 		StopNames:       stopNames,
 		Speed:           t.getSpeed(),
 		State:           t.state,
@@ -413,6 +705,10 @@ func (t *Tram) IsStopped() bool {
 	return t.state == StateStopped || t.state == StateStopping
 }
 
+func (t *Tram) IsBrokenDown() bool {
+	return t.state == StateBrokenDown
+}
+
 func (t *Tram) StopTram() {
 	switch t.state {
 	case StateTravelling, StateStopping:
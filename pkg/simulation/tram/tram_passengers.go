@@ -1,6 +1,8 @@
 package tram
 
 import (
+	"math"
+
 	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/passenger"
 )
 
@@ -8,37 +10,87 @@ func (t *Tram) GetPassengerCount() uint {
 	return uint(len(t.passengersInTram))
 }
 
+// Returns how many passengers can board with the time left at the stop
+func (t *Tram) getBoardingPassengersLimit(freePlaces int) int {
+	var count int
+	timeLeft := t.passengerChangeTime
+
+	for ; count < freePlaces; count++ {
+		boardingTime := t.dwellTimeModel.GetBoardingTime(t.VehicleType, t.GetPassengerCount()+uint(count))
+		if timeLeft < boardingTime {
+			break
+		}
+		timeLeft -= boardingTime
+	}
+
+	return count
+}
+
 func (t *Tram) loadPassengers(time uint) bool {
+	t.passengerChangeTime++
+
+	freePlaces := math.MaxInt
+	if t.VehicleType.IsCapacityLimited() {
+		freePlaces = int(t.VehicleType.GetCapacity()) - len(t.passengersInTram)
+	}
+
 	stopID := t.TripDetails.Trip.Stops[t.TripDetails.Index].ID
-	boardedPassengers := t.passengersStore.LoadPassengers(stopID, t.ID, time)
+	boardedPassengers, arePassengersLeft := t.passengersStore.LoadPassengers(
+		stopID,
+		t.TripDetails.Index,
+		t.ID,
+		time,
+		t.getBoardingPassengersLimit(freePlaces),
+	)
 
 	for _, p := range boardedPassengers {
+		t.passengerChangeTime -= t.dwellTimeModel.GetBoardingTime(t.VehicleType, t.GetPassengerCount())
 		t.passengersInTram[p.ID] = p
 	}
 
 	// return true if loading is finished
-	return len(boardedPassengers) < passenger.MAX_PASSENGERS_CHANGE_RATE
+	if !arePassengersLeft || len(boardedPassengers) == freePlaces {
+		t.passengerChangeTime = 0
+		return true
+	}
+
+	return false
 }
 
 func (t *Tram) unloadPassengers(time uint) bool {
+	t.passengerChangeTime++
+
 	stopID := t.TripDetails.Trip.Stops[t.TripDetails.Index].ID
-	disembarkingPassengers := make([]*passenger.Passenger, 0, passenger.MAX_PASSENGERS_CHANGE_RATE)
+	disembarkingPassengers := make([]*passenger.Passenger, 0)
 
 	for _, p := range t.passengersInTram {
-		if p.TravelPlan.GetConnectionDestination(t.ID) == stopID {
+		// Passengers going to a skipped stop leave the tram at the first stop after it,
+		// all passengers leave the tram withdrawn from service
+		destinationStopID := p.TravelPlan.GetConnectionDestination(t.ID)
+		if destinationStopID == stopID || t.TripDetails.isStopSkipped(destinationStopID) || t.isWithdrawn {
 			disembarkingPassengers = append(disembarkingPassengers, p)
 		}
-		if len(disembarkingPassengers) == passenger.MAX_PASSENGERS_CHANGE_RATE {
+	}
+
+	var count int
+	for ; count < len(disembarkingPassengers); count++ {
+		alightingTime := t.dwellTimeModel.GetAlightingTime(t.VehicleType, t.GetPassengerCount()-uint(count))
+		if t.passengerChangeTime < alightingTime {
 			break
 		}
+		t.passengerChangeTime -= alightingTime
 	}
 
+	isUnloadingFinished := count == len(disembarkingPassengers)
+
+	// Passengers are disembarking in the order of their IDs to keep the results reproducible
+	disembarkingPassengers = passenger.FirstPassengersByID(disembarkingPassengers, count)
+
 	for _, p := range disembarkingPassengers {
 		delete(t.passengersInTram, p.ID)
 	}
 
-	t.passengersStore.UnloadPassengers(disembarkingPassengers, stopID, time)
-	isUnloadingFinished := len(disembarkingPassengers) < passenger.MAX_PASSENGERS_CHANGE_RATE
+	t.passengersStore.UnloadPassengers(disembarkingPassengers, stopID, t.TripDetails.Index, time)
 
 	return isUnloadingFinished
 }
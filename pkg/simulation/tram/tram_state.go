@@ -1,9 +1,9 @@
 package tram
 
 import (
-	"math/rand/v2"
-
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city/graph"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/controlcenter"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/simulation/vehicle"
 )
 
 type TramState uint8
@@ -16,6 +16,7 @@ const (
 	StateTripFinished
 	StateStopping
 	StateStopped
+	StateBrokenDown
 )
 
 var TramStates = []struct {
@@ -29,19 +30,37 @@ var TramStates = []struct {
 	{StateTripFinished, "TRIP_FINISHED"},
 	{StateStopping, "STOPPING"},
 	{StateStopped, "STOPPED"},
+	{StateBrokenDown, "BROKEN_DOWN"},
 }
 
 func (t *Tram) onTripNotStarted(
 	time uint,
 	stopsByID map[uint64]*graph.GraphTramStop,
 ) (result TramPositionChange, update bool) {
-	if time != t.departureTime {
-		return
+	startTime := t.TripDetails.Trip.Stops[0].Time
+
+	if t.pullOutPath != nil {
+		return t.pullOut(time)
+	}
+
+	if t.previousTrip == nil {
+		if time != t.departureTime {
+			return
+		}
+		t.departureTime = startTime
+	} else {
+		// the vehicle is ready for the trip after finishing the previous one and the layover
+		if t.isCancelled {
+			t.cancelTrip()
+			return
+		} else if t.previousTripEndTime == 0 {
+			return
+		}
+		t.departureTime = max(startTime, t.previousTripEndTime+vehicle.MIN_LAYOVER_TIME)
 	}
 
 	t.state = StatePassengersLoading
 	t.TripDetails.saveArrival(time)
-	t.departureTime = t.TripDetails.Trip.Stops[0].Time
 
 	// Set azimuth to any neighbor's azimuth
 	for _, neighbor := range stopsByID[t.TripDetails.Trip.Stops[0].ID].GetNeighbors() {
@@ -64,15 +83,52 @@ func (t *Tram) onTripNotStarted(
 	return
 }
 
+func (t *Tram) pullOut(time uint) (result TramPositionChange, update bool) {
+	// trams pull out late when the simulation starts after their pull-out time
+	if time < t.departureTime {
+		return
+	}
+
+	t.isPullingOut = true
+	t.hasPulledOut, t.pullOutTime = true, time
+	t.departureTime = t.TripDetails.Trip.Stops[0].Time
+	t.detour = t.pullOutPath
+	t.pathIndex = 0
+	t.state = StateTravelling
+	t.lat, t.lon = t.pullOutPath.Nodes[0].GetCoordinates()
+
+	result = TramPositionChange{
+		TramID:  t.ID,
+		Lat:     t.lat,
+		Lon:     t.lon,
+		Azimuth: t.azimuth,
+		State:   t.state,
+	}
+	update = true
+
+	return
+}
+
 func (t *Tram) onPassengersLoading(time uint) {
 	isLoadingFinished := t.loadPassengers(time)
 
-	if !isLoadingFinished || time < t.departureTime {
+	if !isLoadingFinished || time < max(t.departureTime, t.holdingTime) {
+		return
+	}
+
+	nextStopIndex, detour, ok := t.planPathToNextStop()
+	if !ok {
 		return
 	}
 
+	if t.VehicleType.IsCapacityLimited() && t.GetPassengerCount() >= t.VehicleType.GetCapacity() {
+		t.passengersStore.LeavePassengersBehind(t.TripDetails.Trip.Stops[t.TripDetails.Index].ID, t.ID, time)
+	}
+
 	t.TripDetails.saveDeparture(time)
 	t.TripDetails.Index += 1
+	t.TripDetails.skipStopsUntil(nextStopIndex)
+	t.detour = detour
 	t.pathIndex = 0
 	t.state = StateTravelling
 }
@@ -84,32 +140,58 @@ func (t *Tram) onPassengersUnloading(time uint) {
 		return
 	}
 
-	if t.TripDetails.Index == len(t.TripDetails.Trip.Stops)-1 {
-		t.TripDetails.saveDeparture(time)
-		t.state = StateTripFinished
-	} else {
+	if t.TripDetails.Index < len(t.TripDetails.Trip.Stops)-1 && !t.isWithdrawn {
 		t.state = StatePassengersLoading
+		return
+	}
+
+	t.TripDetails.saveDeparture(time)
+
+	if t.isWithdrawn {
+		t.TripDetails.skipRemainingStops()
+		t.pullInPath = t.getWithdrawalPath()
+	}
+
+	if t.pullInPath != nil {
+		t.isPullingIn = true
+		t.detour = t.pullInPath
+		t.pathIndex = 0
+		t.state = StateTravelling
+	} else {
+		t.state = StateTripFinished
 	}
 }
 
 func (t *Tram) onTravelling(time uint) (result TramPositionChange, update bool) {
+	t.rerouteAroundClosedTrack()
 	path := t.getTravelPath()
 
 	if t.distToNextInterNode == 0 {
 		t.setAzimuthAndDistanceToNextNode(path.Nodes)
 	}
 
-	distanceToDrive := t.updateSpeedAndReserveNodes(path)
+	distanceToDrive := t.updateSpeedAndReserveNodes(path, time)
 
 	t.findNewLocation(path.Nodes, distanceToDrive)
-	t.blockNodesBehind()
+	t.blockNodesBehind(time)
 
-	if t.pathIndex == len(path.Nodes)-1 {
+	if t.state == StateTravelling && t.pathIndex < len(path.Nodes)-1 && t.failureModel.isEnabled() &&
+		t.failureModel.isBreakingDown(distanceToDrive, t.random) {
+		t.breakDown(time, path)
+	} else if t.pathIndex == len(path.Nodes)-1 && t.isPullingIn {
+		// the tram is stabled at the depot
+		t.hasPulledIn, t.pullInTime = true, time
+		t.state = StateTripFinished
+	} else if t.pathIndex == len(path.Nodes)-1 {
+		t.isPullingOut = false
 		t.TripDetails.saveArrival(time)
-		t.departureTime = max(
-			t.TripDetails.Trip.Stops[t.TripDetails.Index].Time,
-			time+uint(rand.IntN(11))+15,
-		)
+		stop := t.TripDetails.Trip.Stops[t.TripDetails.Index]
+		t.departureTime = time + t.dwellTimeModel.GetMinimumDwellTime()
+		// trips don't start before their scheduled time
+		if t.TripDetails.Index == 0 || t.controlCenter.IsHeldToSchedule(t.Route.Name, stop.ID) {
+			t.departureTime = max(t.departureTime, stop.Time)
+		}
+		t.passengerChangeTime = 0
 		if t.state == StateStopping {
 			t.prevState = StatePassengersUnloading
 			t.state = StateStopped
@@ -135,6 +217,74 @@ func (t *Tram) onTravelling(time uint) (result TramPositionChange, update bool)
 	return
 }
 
+func (t *Tram) breakDown(time uint, path *controlcenter.Path) {
+	t.speed = 0
+	t.unblockNodesAhead()
+	t.state = StateBrokenDown
+	t.repairTime = time + t.failureModel.getRepairTime(t.random)
+
+	t.incidents = append(t.incidents, Incident{
+		StartTime:      time,
+		EndTime:        t.repairTime,
+		NodeID:         path.Nodes[t.pathIndex].GetID(),
+		Lat:            t.lat,
+		Lon:            t.lon,
+		PassengerCount: t.GetPassengerCount(),
+	})
+}
+
+// Passengers wait in the broken down tram until it's repaired. The repaired tram returns
+// to service or is withdrawn, taking passengers to the next stop and going to the depot.
+func (t *Tram) onBrokenDown(time uint) (result TramPositionChange, update bool) {
+	if time < t.repairTime {
+		return
+	}
+
+	if !t.isPullingIn && t.random.Float64() < t.failureModel.WithdrawalProbability {
+		t.isWithdrawn = true
+		t.incidents[len(t.incidents)-1].IsWithdrawn = true
+	}
+
+	t.state = StateTravelling
+
+	result = TramPositionChange{
+		TramID:  t.ID,
+		Lat:     t.lat,
+		Lon:     t.lon,
+		Azimuth: t.azimuth,
+		State:   t.state,
+	}
+	update = true
+
+	return
+}
+
+// Returns path from the current stop to the depot of the withdrawn tram,
+// or nil if the tram has no depot and ends its service at the stop
+func (t *Tram) getWithdrawalPath() *controlcenter.Path {
+	if t.depotNodeID == 0 {
+		return nil
+	}
+
+	path := t.getTravelPath()
+	withdrawalPath, _, ok := t.controlCenter.GetDetour(path.Nodes[len(path.Nodes)-1:], []uint64{t.depotNodeID})
+	if !ok {
+		return nil
+	}
+
+	return withdrawalPath
+}
+
+// Trips of a block after the withdrawn tram are cancelled
+func (t *Tram) cancelTrip() {
+	for i := range t.TripDetails.Skipped {
+		t.TripDetails.Skipped[i] = true
+	}
+
+	t.state = StateTripFinished
+	t.isFinished = true
+}
+
 func (t *Tram) onTripFinished() (result TramPositionChange, update bool) {
 	if t.isFinished {
 		return
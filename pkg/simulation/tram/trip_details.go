@@ -8,6 +8,7 @@ type tripDetails struct {
 	Trip                 *trip.TramTrip
 	Index                int
 	Arrivals, Departures []uint
+	Skipped              []bool
 }
 
 func newTripDetails(trip *trip.TramTrip) tripDetails {
@@ -15,9 +16,51 @@ func newTripDetails(trip *trip.TramTrip) tripDetails {
 		Trip:       trip,
 		Arrivals:   make([]uint, len(trip.Stops)),
 		Departures: make([]uint, len(trip.Stops)),
+		Skipped:    make([]bool, len(trip.Stops)),
 	}
 }
 
+// Moves to the stop with the given index, marking stops in between as skipped
+func (t *tripDetails) skipStopsUntil(index int) {
+	for ; t.Index < index; t.Index++ {
+		t.Skipped[t.Index] = true
+	}
+}
+
+// Marks stops after the current one as skipped, when the trip ends early
+func (t *tripDetails) skipRemainingStops() {
+	for i := t.Index + 1; i < len(t.Skipped); i++ {
+		t.Skipped[i] = true
+	}
+}
+
+// Returns index of the last visited stop before the current one
+func (t *tripDetails) getPreviousIndex() int {
+	index := t.Index - 1
+	for index > 0 && t.Skipped[index] {
+		index--
+	}
+	return index
+}
+
+// Returns true if the stop was skipped before reaching the current stop
+func (t *tripDetails) isStopSkipped(stopID uint64) bool {
+	for i := t.Index - 1; i > 0; i-- {
+		if t.Skipped[i] && t.Trip.Stops[i].ID == stopID {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tripDetails) getStopIDsFrom(index int) []uint64 {
+	stopIDs := make([]uint64, 0, len(t.Trip.Stops)-index)
+	for _, stop := range t.Trip.Stops[index:] {
+		stopIDs = append(stopIDs, stop.ID)
+	}
+	return stopIDs
+}
+
 func (t *tripDetails) saveArrival(time uint) {
 	t.Arrivals[t.Index] = time
 }
@@ -34,7 +77,8 @@ func (t *tripDetails) getDelay(time uint) uint {
 		return time - t.Trip.Stops[0].Time
 	}
 
-	departureDelay := t.Departures[t.Index-1] - t.Trip.Stops[t.Index-1].Time
+	previousIndex := t.getPreviousIndex()
+	departureDelay := t.Departures[previousIndex] - t.Trip.Stops[previousIndex].Time
 	if time < t.Trip.Stops[t.Index].Time {
 		return departureDelay
 	}
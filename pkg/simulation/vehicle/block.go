@@ -0,0 +1,167 @@
+package vehicle
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
+)
+
+const (
+	// Minimum time a tram spends at the terminal between trips
+	MIN_LAYOVER_TIME = 3 * 60 // 3 minutes
+	// Trips are not chained when the tram would wait longer at the terminal
+	MAX_LAYOVER_TIME = 30 * 60 // 30 minutes
+)
+
+// Sequence of trips done by a single tram, ordered by their departure time
+type Block struct {
+	ID      uint
+	TripIDs []uint
+}
+
+type blockEnd struct {
+	blockIndex  int
+	stopGroup   string
+	arrivalTime uint
+	vehicleType *VehicleType
+}
+
+func compareTrips(t1, t2 *trip.TramTrip) int {
+	return cmp.Or(cmp.Compare(t1.Stops[0].Time, t2.Stops[0].Time), cmp.Compare(t1.ID, t2.ID))
+}
+
+// Chains trips of each route into blocks. A trip is done by the tram which arrived
+// first at the terminal it departs from, if it can make the layover and has the vehicle
+// type assigned to the trip.
+func InferBlocks(c *city.City, fleet *Fleet) []Block {
+	var blocks []Block
+
+	for _, route := range c.GetTramRoutes() {
+		trips := make([]*trip.TramTrip, 0, len(route.Trips))
+		for i := range route.Trips {
+			trips = append(trips, &route.Trips[i])
+		}
+		slices.SortFunc(trips, compareTrips)
+
+		// trams waiting at terminals, ordered by their arrival time
+		var blockEnds []blockEnd
+
+		for _, tramTrip := range trips {
+			firstStop, lastStop := tramTrip.Stops[0], tramTrip.Stops[len(tramTrip.Stops)-1]
+			stopGroup := c.GetStopByID(firstStop.ID).GetGroupName()
+			vehicleType := fleet.GetVehicleType(route.Name, tramTrip.ID)
+
+			endIndex := slices.IndexFunc(blockEnds, func(end blockEnd) bool {
+				return end.stopGroup == stopGroup && end.vehicleType == vehicleType &&
+					end.arrivalTime+MIN_LAYOVER_TIME <= firstStop.Time &&
+					firstStop.Time <= end.arrivalTime+MAX_LAYOVER_TIME
+			})
+
+			var blockIndex int
+			if endIndex >= 0 {
+				blockIndex = blockEnds[endIndex].blockIndex
+				blocks[blockIndex].TripIDs = append(blocks[blockIndex].TripIDs, tramTrip.ID)
+				blockEnds = slices.Delete(blockEnds, endIndex, endIndex+1)
+			} else {
+				blockIndex = len(blocks)
+				blocks = append(blocks, Block{ID: uint(blockIndex + 1), TripIDs: []uint{tramTrip.ID}})
+			}
+
+			end := blockEnd{
+				blockIndex:  blockIndex,
+				stopGroup:   c.GetStopByID(lastStop.ID).GetGroupName(),
+				arrivalTime: lastStop.Time,
+				vehicleType: vehicleType,
+			}
+
+			insertIndex, _ := slices.BinarySearchFunc(blockEnds, end, func(e1, e2 blockEnd) int {
+				return cmp.Compare(e1.arrivalTime, e2.arrivalTime+1)
+			})
+			blockEnds = slices.Insert(blockEnds, insertIndex, end)
+		}
+	}
+
+	return blocks
+}
+
+// Reads blocks from CSV file with block_id and trip_id columns.
+// Trips which are not assigned to any block are done by separate trams,
+// their blocks are numbered after the last block from the file.
+func BlocksFromCSV(c *city.City, data []byte) ([]Block, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading blocks: %w", err)
+	}
+
+	if len(header) != 2 || header[0] != "block_id" || header[1] != "trip_id" {
+		return nil, fmt.Errorf("expected block_id,trip_id header in blocks file, got %v", header)
+	}
+
+	tripsByID := c.GetTripsByID()
+	tripsByBlockID := make(map[uint64][]*trip.TramTrip)
+	blockIDsByTripID := make(map[uint]uint64)
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading blocks: %w", err)
+		}
+
+		blockID, err := strconv.ParseUint(row[0], 10, 64)
+		if err != nil || blockID == 0 {
+			return nil, fmt.Errorf("line %d: invalid block_id %q", line, row[0])
+		}
+
+		tripID, err := strconv.ParseUint(row[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid trip_id %q", line, row[1])
+		}
+
+		tramTrip, ok := tripsByID[uint(tripID)]
+		if !ok {
+			return nil, fmt.Errorf("line %d: trip %d not found", line, tripID)
+		}
+
+		if otherBlockID, ok := blockIDsByTripID[tramTrip.ID]; ok {
+			return nil, fmt.Errorf("line %d: trip %d is already assigned to block %d", line, tripID, otherBlockID)
+		}
+
+		blockIDsByTripID[tramTrip.ID] = blockID
+		tripsByBlockID[blockID] = append(tripsByBlockID[blockID], tramTrip)
+	}
+
+	var blocks []Block
+	var lastBlockID uint64
+	for _, blockID := range slices.Sorted(maps.Keys(tripsByBlockID)) {
+		trips := tripsByBlockID[blockID]
+		slices.SortFunc(trips, compareTrips)
+
+		block := Block{ID: uint(blockID)}
+		for _, tramTrip := range trips {
+			block.TripIDs = append(block.TripIDs, tramTrip.ID)
+		}
+		blocks = append(blocks, block)
+		lastBlockID = blockID
+	}
+
+	for _, tripID := range slices.Sorted(maps.Keys(tripsByID)) {
+		if _, ok := blockIDsByTripID[tripID]; !ok {
+			lastBlockID++
+			blocks = append(blocks, Block{ID: uint(lastBlockID), TripIDs: []uint{tripID}})
+		}
+	}
+
+	return blocks, nil
+}
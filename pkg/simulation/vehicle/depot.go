@@ -0,0 +1,82 @@
+package vehicle
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+)
+
+// Time a tram leaves the depot before it's expected at the first stop of its block
+const PULL_OUT_MARGIN = 3 * 60 // 3 minutes
+
+// Depot is a graph node where trams are stabled before pulling out
+// to the first trip of their block and after pulling in from the last one.
+// Each stabling track holds a single tram.
+type Depot struct {
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	NodeID         uint64 `json:"nodeID"`
+	StablingTracks uint   `json:"stablingTracks"`
+}
+
+// Number of trams stabled at the depot since the given time
+type DepotOccupancy struct {
+	Time         uint `json:"time"`
+	VehicleCount uint `json:"vehicleCount"`
+}
+
+// Tram leaving or entering the depot at the given time
+type DepotMovement struct {
+	Time     uint
+	IsPullIn bool
+}
+
+func ValidateDepots(c *city.City, depots []Depot) error {
+	nodesByID := c.GetNodesByID()
+	depotIDs := make(map[uint]bool, len(depots))
+
+	for _, depot := range depots {
+		switch {
+		case depot.ID == 0:
+			return fmt.Errorf("depot %q: ID has to be positive", depot.Name)
+		case depotIDs[depot.ID]:
+			return fmt.Errorf("depot %d is defined more than once", depot.ID)
+		case depot.StablingTracks == 0:
+			return fmt.Errorf("depot %d: number of stabling tracks has to be positive", depot.ID)
+		}
+
+		if _, ok := nodesByID[depot.NodeID]; !ok {
+			return fmt.Errorf("depot %d: node %d not found", depot.ID, depot.NodeID)
+		}
+
+		depotIDs[depot.ID] = true
+	}
+
+	return nil
+}
+
+// Returns changes of the depot occupancy, starting with trams stabled before any movement
+func GetDepotOccupancy(stabledVehicles uint, movements []DepotMovement) []DepotOccupancy {
+	slices.SortFunc(movements, func(m1, m2 DepotMovement) int {
+		return cmp.Compare(m1.Time, m2.Time)
+	})
+
+	occupancy := []DepotOccupancy{{VehicleCount: stabledVehicles}}
+	for _, movement := range movements {
+		last := &occupancy[len(occupancy)-1]
+		if last.Time != movement.Time {
+			occupancy = append(occupancy, DepotOccupancy{Time: movement.Time, VehicleCount: last.VehicleCount})
+			last = &occupancy[len(occupancy)-1]
+		}
+
+		if movement.IsPullIn {
+			last.VehicleCount++
+		} else {
+			last.VehicleCount--
+		}
+	}
+
+	return occupancy
+}
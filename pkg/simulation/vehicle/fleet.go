@@ -0,0 +1,115 @@
+package vehicle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+)
+
+// Assignment of vehicle types to trips. Vehicle type of a trip is looked up
+// by trip ID first, then by route name, then the default vehicle type is used.
+type Fleet struct {
+	VehicleTypes []VehicleType     `json:"vehicleTypes"`
+	Default      string            `json:"default,omitempty"`
+	Routes       map[string]string `json:"routes,omitempty"`
+	Trips        map[uint]string   `json:"trips,omitempty"`
+	typesByName  map[string]*VehicleType
+}
+
+func FleetFromJSON(data []byte) (Fleet, error) {
+	fleet := Fleet{typesByName: make(map[string]*VehicleType)}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fleet); err != nil {
+		return Fleet{}, fmt.Errorf("error reading vehicle types: %w", err)
+	}
+
+	for i := range fleet.VehicleTypes {
+		vehicleType := &fleet.VehicleTypes[i]
+		if err := vehicleType.validate(); err != nil {
+			return Fleet{}, err
+		}
+
+		if _, ok := fleet.typesByName[vehicleType.Name]; ok {
+			return Fleet{}, fmt.Errorf("vehicle type %q is defined more than once", vehicleType.Name)
+		}
+
+		fleet.typesByName[vehicleType.Name] = vehicleType
+	}
+
+	if fleet.Default != "" {
+		if err := fleet.checkVehicleType(fleet.Default, "default"); err != nil {
+			return Fleet{}, err
+		}
+	}
+
+	for routeName, vehicleTypeName := range fleet.Routes {
+		if err := fleet.checkVehicleType(vehicleTypeName, fmt.Sprintf("route %s", routeName)); err != nil {
+			return Fleet{}, err
+		}
+	}
+
+	for tripID, vehicleTypeName := range fleet.Trips {
+		if err := fleet.checkVehicleType(vehicleTypeName, fmt.Sprintf("trip %d", tripID)); err != nil {
+			return Fleet{}, err
+		}
+	}
+
+	return fleet, nil
+}
+
+func (f *Fleet) checkVehicleType(name, assignedTo string) error {
+	if _, ok := f.typesByName[name]; !ok {
+		return fmt.Errorf("unknown vehicle type %q assigned to %s", name, assignedTo)
+	}
+
+	return nil
+}
+
+func (f *Fleet) GetVehicleType(routeName string, tripID uint) *VehicleType {
+	if vehicleTypeName, ok := f.Trips[tripID]; ok {
+		return f.typesByName[vehicleTypeName]
+	}
+
+	if vehicleTypeName, ok := f.Routes[routeName]; ok {
+		return f.typesByName[vehicleTypeName]
+	}
+
+	if vehicleType, ok := f.typesByName[f.Default]; ok {
+		return vehicleType
+	}
+
+	return &DEFAULT_VEHICLE_TYPE
+}
+
+// Checks if all trips of each block have the same vehicle type assigned,
+// as they are done by a single vehicle
+func (f *Fleet) ValidateBlocks(c *city.City, blocks []Block) error {
+	vehicleTypesByTripID := make(map[uint]*VehicleType)
+	for _, route := range c.GetTramRoutes() {
+		for _, tramTrip := range route.Trips {
+			vehicleTypesByTripID[tramTrip.ID] = f.GetVehicleType(route.Name, tramTrip.ID)
+		}
+	}
+
+	for _, block := range blocks {
+		firstTripID := block.TripIDs[0]
+		for _, tripID := range block.TripIDs[1:] {
+			if vehicleTypesByTripID[tripID] != vehicleTypesByTripID[firstTripID] {
+				return fmt.Errorf(
+					"block %d: trip %d has vehicle type %q, but trip %d has vehicle type %q",
+					block.ID,
+					tripID,
+					vehicleTypesByTripID[tripID].Name,
+					firstTripID,
+					vehicleTypesByTripID[firstTripID].Name,
+				)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package vehicle
+
+import "fmt"
+
+type VehicleType struct {
+	Name string `json:"name"`
+	// length in meters
+	Length float32 `json:"length"`
+	// acceleration and deceleration in m/s^2
+	MaxAcceleration float32 `json:"maxAcceleration"`
+	MaxDeceleration float32 `json:"maxDeceleration"`
+	// speed in m/s, 0 if only speed limits of the track apply
+	MaxSpeed float32 `json:"maxSpeed"`
+	// capacity is unlimited if both seated and standing capacity are 0
+	SeatedCapacity   uint `json:"seatedCapacity"`
+	StandingCapacity uint `json:"standingCapacity"`
+}
+
+// Vehicle type used for trips which don't have any vehicle type assigned,
+// with unlimited capacity and speed limited only by the track
+var DEFAULT_VEHICLE_TYPE = VehicleType{
+	Name:            "default",
+	Length:          30,
+	MaxAcceleration: 1.0,
+	MaxDeceleration: 1.0,
+}
+
+// Returns total capacity of the vehicle, 0 if it's unlimited
+func (v *VehicleType) GetCapacity() uint {
+	return v.SeatedCapacity + v.StandingCapacity
+}
+
+func (v *VehicleType) IsCapacityLimited() bool {
+	return v.GetCapacity() > 0
+}
+
+func (v *VehicleType) validate() error {
+	switch {
+	case v.Name == "":
+		return fmt.Errorf("vehicle type has to have a name")
+	case v.Length <= 0:
+		return fmt.Errorf("vehicle type %q: length has to be positive", v.Name)
+	case v.MaxAcceleration <= 0:
+		return fmt.Errorf("vehicle type %q: max acceleration has to be positive", v.Name)
+	case v.MaxDeceleration <= 0:
+		return fmt.Errorf("vehicle type %q: max deceleration has to be positive", v.Name)
+	case v.MaxSpeed < 0:
+		return fmt.Errorf("vehicle type %q: max speed can't be negative", v.Name)
+	}
+
+	return nil
+}
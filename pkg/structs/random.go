@@ -0,0 +1,23 @@
+package structs
+
+import "math/rand/v2"
+
+type RandomStream uint64
+
+const (
+	TramRandomStream RandomStream = iota + 1
+	PassengerGeneratorRandomStream
+	TravelPlanRandomStream
+)
+
+// Creates a random number generator for a single simulation component, e.g. a tram
+// with the given ID. Each component has its own generator, so the results don't depend
+// on the order in which components are processed.
+func NewRandom(seed uint64, stream RandomStream, id uint64) *rand.Rand {
+	return rand.New(NewRandomSource(seed, stream, id))
+}
+
+// Creates a source for NewRandom, which can be used to save and restore its state.
+func NewRandomSource(seed uint64, stream RandomStream, id uint64) *rand.PCG {
+	return rand.NewPCG(seed, uint64(stream)<<56|id)
+}
@@ -0,0 +1,133 @@
+package travelplan
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+// Disutility of a minute of travel time, a transfer and a minute of transfer time
+type ChoiceWeights struct {
+	TravelTime   float64 `json:"travelTime"`
+	Transfers    float64 `json:"transfers"`
+	TransferTime float64 `json:"transferTime"`
+}
+
+// Weights of passenger classes given by their strategies
+var DEFAULT_CHOICE_WEIGHTS = map[TravelPlanStrategy]ChoiceWeights{
+	ASAP:    {TravelTime: 1, Transfers: 2, TransferTime: 1},
+	COMFORT: {TravelTime: 0.5, Transfers: 10, TransferTime: 1.5},
+	SURE:    {TravelTime: 1, Transfers: 3, TransferTime: 0.25},
+}
+
+// Logit model of choice between journeys of the Pareto set. A passenger chooses a journey
+// with probability proportional to exp(-cost), where cost is the sum of travel time since spawn,
+// number of transfers and transfer time multiplied by weights of the passenger class.
+// Classes are named after strategies, classes without weights use the default ones.
+type ChoiceModel struct {
+	Weights map[string]ChoiceWeights `json:"weights,omitempty"`
+}
+
+func (m *ChoiceModel) Validate() error {
+	classes := structs.NewSet[TravelPlanStrategy]()
+
+	for name, weights := range m.Weights {
+		class := TravelPlanStrategy(strings.ToUpper(name))
+		if _, ok := DEFAULT_CHOICE_WEIGHTS[class]; !ok {
+			return fmt.Errorf("unknown passenger class %q", name)
+		} else if classes.Includes(class) {
+			return fmt.Errorf("passenger class %q is given more than once", name)
+		}
+		classes.Add(class)
+
+		if weights.TravelTime < 0 || weights.Transfers < 0 || weights.TransferTime < 0 {
+			return fmt.Errorf("weights of passenger class %q can't be negative", name)
+		}
+	}
+
+	return nil
+}
+
+func (m *ChoiceModel) getWeights(strategy TravelPlanStrategy) ChoiceWeights {
+	for name, weights := range m.Weights {
+		if TravelPlanStrategy(strings.ToUpper(name)) == strategy {
+			return weights
+		}
+	}
+
+	if weights, ok := DEFAULT_CHOICE_WEIGHTS[strategy]; ok {
+		return weights
+	}
+
+	panic(fmt.Sprintf("Unknown strategy: %s", strategy))
+}
+
+func (w ChoiceWeights) getCost(criteria JourneyCriteria, spawnTime uint) float64 {
+	return w.TravelTime*float64(criteria.ArrivalTime-spawnTime)/60 +
+		w.Transfers*float64(criteria.Transfers) +
+		w.TransferTime*float64(criteria.TransferTime)/60
+}
+
+// Returns travel plan with a journey of the Pareto set chosen with the choice model
+func GetChoiceTravelPlan(
+	currentCity *city.City,
+	model *ChoiceModel,
+	strategy TravelPlanStrategy,
+	startStopIDs []uint64,
+	endStopIDs structs.Set[uint64],
+	spawnTime uint,
+	random *rand.Rand,
+) (TravelPlan, bool) {
+	weights := model.getWeights(strategy)
+
+	var offsetBetweenTransfers uint
+	if strategy == SURE {
+		offsetBetweenTransfers = SURE_TRANSFER_OFFSET
+	}
+
+	query := newRaptorQuery(currentCity, startStopIDs, slices.Sorted(endStopIDs.GetItems()), spawnTime, offsetBetweenTransfers, false)
+	journeys := query.getParetoJourneys()
+	if len(journeys) == 0 {
+		return TravelPlan{}, false
+	}
+
+	costs := make([]float64, len(journeys))
+	for i, journey := range journeys {
+		costs[i] = weights.getCost(journey.JourneyCriteria, spawnTime)
+	}
+
+	journey := journeys[chooseWithLogit(costs, random)]
+
+	travelPlan := NewTravelPlan(journey.getStartStopID(), endStopIDs, spawnTime)
+	journey.addToTravelPlan(&travelPlan)
+
+	return travelPlan, true
+}
+
+// Returns index of the alternative drawn with probability proportional to exp(-cost)
+func chooseWithLogit(costs []float64, random *rand.Rand) int {
+	// Costs are shifted by the minimum to avoid underflow of all probabilities
+	minCost := slices.Min(costs)
+
+	probabilities := make([]float64, len(costs))
+	var sum float64
+	for i, cost := range costs {
+		probabilities[i] = math.Exp(minCost - cost)
+		sum += probabilities[i]
+	}
+
+	value := random.Float64() * sum
+	for i, probability := range probabilities {
+		if value < probability {
+			return i
+		}
+		value -= probability
+	}
+
+	return len(costs) - 1
+}
@@ -14,6 +14,8 @@ func GetTravelPlan(
 	strategy TravelPlanStrategy,
 	startStopIDs, endStopIDs []uint64,
 	spawnTime uint,
+	choiceModel *ChoiceModel,
+	random *rand.Rand,
 ) (TravelPlan, bool) {
 	var (
 		travelPlan TravelPlan
@@ -30,16 +32,21 @@ func GetTravelPlan(
 		return TravelPlan{}, false
 	}
 
+	// With choice model, passengers of other strategies choose between Pareto-optimal journeys
+	if choiceModel != nil && strategy != RANDOM {
+		return GetChoiceTravelPlan(currentCity, choiceModel, strategy, startStopIDs, endStops, spawnTime, random)
+	}
+
 	switch strategy {
 	case RANDOM:
-		startStopID := startStopIDs[rand.IntN(len(startStopIDs))]
-		travelPlan, ok = GetRandomTravelPlan(currentCity, startStopID, spawnTime)
+		startStopID := startStopIDs[random.IntN(len(startStopIDs))]
+		travelPlan, ok = GetRandomTravelPlan(currentCity, startStopID, spawnTime, random)
 	case COMFORT:
 		travelPlan, ok = GetComfortTravelPlan(currentCity, startStopIDs, endStops, spawnTime)
 	case ASAP:
 		travelPlan, ok = GetFastestTravelPlan(currentCity, startStopIDs, endStops, spawnTime, 0)
 	case SURE:
-		travelPlan, ok = GetFastestTravelPlan(currentCity, startStopIDs, endStops, spawnTime, 5*60) // 5 minutes
+		travelPlan, ok = GetFastestTravelPlan(currentCity, startStopIDs, endStops, spawnTime, SURE_TRANSFER_OFFSET)
 	default:
 		panic(fmt.Sprintf("Unknown strategy: %s", strategy))
 	}
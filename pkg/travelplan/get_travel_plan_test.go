@@ -0,0 +1,137 @@
+package travelplan
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+// Generated 6x6 grid city with 12 lines crossing at stop groups, running from 5:00 to 7:00
+const BENCHMARK_CITY_FILENAME = "testdata/grid_city.json"
+
+type benchmarkJourney struct {
+	startStopIDs, endStopIDs []uint64
+	spawnTime                uint
+}
+
+// Returns journeys between all pairs of stop groups of the city, spawning between 5:00 and 6:00
+func getBenchmarkJourneys(tb testing.TB) (*city.City, []benchmarkJourney) {
+	c := &city.City{}
+	if err := c.LoadFromFile("grid", BENCHMARK_CITY_FILENAME); err != nil {
+		tb.Fatal(err)
+	}
+
+	stopsByName := c.GetStopsByName()
+	names := slices.Sorted(maps.Keys(stopsByName))
+
+	journeys := make([]benchmarkJourney, 0, len(names)*(len(names)-1))
+	for i, startName := range names {
+		for j, endName := range names {
+			if i == j {
+				continue
+			}
+
+			journeys = append(journeys, benchmarkJourney{
+				startStopIDs: slices.Sorted(maps.Keys(stopsByName[startName])),
+				endStopIDs:   slices.Sorted(maps.Keys(stopsByName[endName])),
+				spawnTime:    5*3600 + uint(len(journeys)*37%3600),
+			})
+		}
+	}
+
+	return c, journeys
+}
+
+func newEndStops(endStopIDs []uint64) structs.Set[uint64] {
+	endStops := structs.NewSet[uint64]()
+	for _, stopID := range endStopIDs {
+		endStops.Add(stopID)
+	}
+
+	return endStops
+}
+
+// Returns arrival time and trip count of the best path found by the reference builder,
+// the earliest arriving one with the fewest trips, or the earliest arriving one among paths
+// with the fewest trips for COMFORT strategy
+func getBestPriorityQueuePath(paths []pqTripSequence, strategy TravelPlanStrategy) (arrivalTime, tripCount uint) {
+	best := slices.MinFunc(paths, func(p1, p2 pqTripSequence) int {
+		if strategy == COMFORT {
+			return cmp.Or(cmp.Compare(p1.tripCount(), p2.tripCount()), cmp.Compare(p1.getArrivalTime(), p2.getArrivalTime()))
+		}
+		return cmp.Or(cmp.Compare(p1.getArrivalTime(), p2.getArrivalTime()), cmp.Compare(p1.tripCount(), p2.tripCount()))
+	})
+
+	return best.getArrivalTime(), best.tripCount()
+}
+
+// RAPTOR finds journeys with the same arrival time and trip count as the priority queue builders
+func TestRaptorMatchesPriorityQueueBuilders(t *testing.T) {
+	c, journeys := getBenchmarkJourneys(t)
+
+	for _, strategy := range []TravelPlanStrategy{ASAP, COMFORT, SURE} {
+		var offsetBetweenTransfers uint
+		if strategy == SURE {
+			offsetBetweenTransfers = SURE_TRANSFER_OFFSET
+		}
+
+		for _, journey := range journeys {
+			paths := getPriorityQueuePaths(c, strategy, journey.startStopIDs, newEndStops(journey.endStopIDs), journey.spawnTime)
+
+			query := newRaptorQuery(c, journey.startStopIDs, journey.endStopIDs, journey.spawnTime, offsetBetweenTransfers, strategy == COMFORT)
+			raptorJourney, ok := query.getJourney()
+
+			if ok != (len(paths) > 0) {
+				t.Errorf("%s from %v at %d: journey found by RAPTOR: %t, by priority queue: %t", strategy, journey.startStopIDs, journey.spawnTime, ok, len(paths) > 0)
+				continue
+			}
+
+			if !ok {
+				continue
+			}
+
+			arrivalTime, tripCount := getBestPriorityQueuePath(paths, strategy)
+			raptorArrivalTime := raptorJourney.trips[len(raptorJourney.trips)-1].arrivalTime
+
+			if raptorArrivalTime != arrivalTime || raptorJourney.tripCount() != tripCount {
+				t.Errorf(
+					"%s from %v to %v at %d: RAPTOR arrives at %d with %d trips, priority queue at %d with %d trips",
+					strategy,
+					journey.startStopIDs,
+					journey.endStopIDs,
+					journey.spawnTime,
+					raptorArrivalTime,
+					raptorJourney.tripCount(),
+					arrivalTime,
+					tripCount,
+				)
+			}
+		}
+	}
+}
+
+func BenchmarkGetTravelPlan(b *testing.B) {
+	c, journeys := getBenchmarkJourneys(b)
+
+	for _, strategy := range []TravelPlanStrategy{ASAP, COMFORT, SURE} {
+		b.Run("RAPTOR/"+string(strategy), func(b *testing.B) {
+			for b.Loop() {
+				for _, journey := range journeys {
+					GetTravelPlan(c, strategy, journey.startStopIDs, journey.endStopIDs, journey.spawnTime, nil, nil)
+				}
+			}
+		})
+
+		b.Run("PriorityQueue/"+string(strategy), func(b *testing.B) {
+			for b.Loop() {
+				for _, journey := range journeys {
+					getPriorityQueueTravelPlan(c, strategy, journey.startStopIDs, newEndStops(journey.endStopIDs), journey.spawnTime)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package travelplan
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+// Earliest arrival at a stop and travel time since leaving the start stops
+type StopArrival struct {
+	StopID      uint64 `json:"stopID"`
+	ArrivalTime uint   `json:"arrivalTime"`
+	TravelTime  uint   `json:"travelTime"`
+}
+
+// Returns earliest arrivals at stops reachable from the start stops within the maximum travel time,
+// sorted by arrival time. Trips are taken at their planned arrivals, changing stops of a group
+// takes the transfer time, and passengers can board a trip at the time they arrived at the stop.
+func GetEarliestArrivals(currentCity *city.City, startStopIDs []uint64, departureTime, maxTravelTime uint) []StopArrival {
+	maxArrivalTime := departureTime + maxTravelTime
+	arrivalTimes := make(map[uint64]uint)
+	stopsToProcess := structs.NewPriorityQueueOrdered[uint64, uint]()
+
+	updateArrival := func(stopID uint64, arrivalTime uint) {
+		if bestTime, ok := arrivalTimes[stopID]; arrivalTime > maxArrivalTime || ok && bestTime <= arrivalTime {
+			return
+		}
+
+		arrivalTimes[stopID] = arrivalTime
+		stopsToProcess.Push(stopID, arrivalTime)
+	}
+
+	for _, stopID := range startStopIDs {
+		updateArrival(stopID, departureTime)
+	}
+
+	// Stops of a trip after the earliest boarded one are already updated by the trip
+	boardedStopIndexByTripID := make(map[uint]int)
+	processedStops := structs.NewSet[uint64]()
+
+	for stopsToProcess.Len() > 0 {
+		stopID := stopsToProcess.Pop()
+		if processedStops.Includes(stopID) {
+			continue
+		}
+		processedStops.Add(stopID)
+
+		arrivalTime := arrivalTimes[stopID]
+
+		for _, arrival := range currentCity.GetPlannedArrivalsInTimeSpan(stopID, arrivalTime, maxArrivalTime) {
+			tramTrip := currentCity.GetTripByID(arrival.TripID)
+
+			endStopIndex := len(tramTrip.Stops)
+			if boardedStopIndex, ok := boardedStopIndexByTripID[arrival.TripID]; ok {
+				if boardedStopIndex <= arrival.StopIndex {
+					continue
+				}
+				endStopIndex = boardedStopIndex
+			}
+			boardedStopIndexByTripID[arrival.TripID] = arrival.StopIndex
+
+			for _, tripStop := range tramTrip.Stops[arrival.StopIndex+1 : endStopIndex] {
+				updateArrival(tripStop.ID, tripStop.Time)
+			}
+		}
+
+		for _, groupStopID := range currentCity.GetStopIDsInGroup(stopID) {
+			if groupStopID != stopID {
+				updateArrival(groupStopID, arrivalTime+TRANSFER_TIME)
+			}
+		}
+	}
+
+	result := make([]StopArrival, 0, len(arrivalTimes))
+	for stopID, arrivalTime := range arrivalTimes {
+		result = append(result, StopArrival{
+			StopID:      stopID,
+			ArrivalTime: arrivalTime,
+			TravelTime:  arrivalTime - departureTime,
+		})
+	}
+
+	slices.SortFunc(result, func(a1, a2 StopArrival) int {
+		return cmp.Or(cmp.Compare(a1.ArrivalTime, a2.ArrivalTime), cmp.Compare(a1.StopID, a2.StopID))
+	})
+
+	return result
+}
@@ -0,0 +1,50 @@
+package travelplan
+
+import (
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+// Additional time between trips of passengers with SURE strategy
+const SURE_TRANSFER_OFFSET = 5 * 60 // 5 minutes
+
+// Returns travel plan with the earliest arrival, allowing the offset between transfers
+func GetFastestTravelPlan(
+	currentCity *city.City,
+	startStopIDs []uint64,
+	endStopIDs structs.Set[uint64],
+	spawnTime uint,
+	offsetBetweenTransfers uint,
+) (TravelPlan, bool) {
+	query := newRaptorQuery(currentCity, startStopIDs, slices.Sorted(endStopIDs.GetItems()), spawnTime, offsetBetweenTransfers, false)
+	return getJourneysTravelPlan(query, endStopIDs)
+}
+
+// Returns travel plan with the fewest trips, arriving the earliest among them
+func GetComfortTravelPlan(
+	currentCity *city.City,
+	startStopIDs []uint64,
+	endStopIDs structs.Set[uint64],
+	spawnTime uint,
+) (TravelPlan, bool) {
+	query := newRaptorQuery(currentCity, startStopIDs, slices.Sorted(endStopIDs.GetItems()), spawnTime, 0, true)
+	return getJourneysTravelPlan(query, endStopIDs)
+}
+
+func getJourneysTravelPlan(query raptorQuery, endStopIDs structs.Set[uint64]) (TravelPlan, bool) {
+	journeys, ok := query.getJourneys()
+	if !ok {
+		return TravelPlan{}, false
+	}
+
+	travelPlan := NewTravelPlan(journeys[0].getStartStopID(), endStopIDs, query.spawnTime)
+
+	// Connections of the best journey take precedence over the alternatives
+	for _, journey := range slices.Backward(journeys) {
+		journey.addToTravelPlan(&travelPlan)
+	}
+
+	return travelPlan, true
+}
@@ -0,0 +1,91 @@
+package travelplan
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/api"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+// Live estimates of trips, given by the trams running them
+type LiveEstimates interface {
+	// Returns estimated time of the trip at the stop with the given index,
+	// or false if the trip won't depart from there, e.g. its tram is stopped
+	GetEstimatedTime(tripID uint, stopIndex int) (uint, bool)
+}
+
+// Returns travel plan from the stop, where trips are taken at their estimated times
+// instead of the scheduled ones, or at the scheduled times if live estimates are nil.
+// Connections of the travel plan keep the scheduled times.
+func GetLiveTravelPlan(
+	currentCity *city.City,
+	strategy TravelPlanStrategy,
+	stopID uint64,
+	endStopIDs structs.Set[uint64],
+	time uint,
+	liveEstimates LiveEstimates,
+) (TravelPlan, bool) {
+	var offsetBetweenTransfers uint
+	var preferFewerTrips bool
+
+	switch strategy {
+	case COMFORT:
+		preferFewerTrips = true
+	case ASAP:
+	case SURE:
+		offsetBetweenTransfers = SURE_TRANSFER_OFFSET
+	default:
+		panic(fmt.Sprintf("Unknown strategy: %s", strategy))
+	}
+
+	query := newRaptorQuery(currentCity, []uint64{stopID}, slices.Sorted(endStopIDs.GetItems()), time, offsetBetweenTransfers, preferFewerTrips)
+	query.liveEstimates = liveEstimates
+
+	journey, ok := query.getJourney()
+	if !ok {
+		return TravelPlan{}, false
+	}
+
+	travelPlan := NewTravelPlan(stopID, endStopIDs, time)
+	journey.addToTravelPlan(&travelPlan)
+
+	return travelPlan, true
+}
+
+// Returns the smallest estimated delay of connections from the stop,
+// or false if none of them is going to depart
+func (tp TravelPlan) GetConnectionDelay(c *city.City, stopID uint64, liveEstimates LiveEstimates) (uint, bool) {
+	stop, ok := tp.stops[stopID]
+	if !ok {
+		return 0, false
+	}
+
+	var delay uint
+	isDeparting := false
+
+	for _, conn := range stop.connections {
+		departureTime := conn.arrivalTime - conn.travelTime
+		tramTrip := c.GetTripByID(conn.id)
+
+		stopIndex := slices.IndexFunc(tramTrip.Stops, func(tripStop api.ResponseTramTripStop) bool {
+			return tripStop.ID == stopID && tripStop.Time == departureTime
+		})
+		if stopIndex == -1 {
+			continue
+		}
+
+		estimatedTime, ok := liveEstimates.GetEstimatedTime(conn.id, stopIndex)
+		if !ok {
+			continue
+		}
+
+		connectionDelay := max(estimatedTime, departureTime) - departureTime
+		if !isDeparting || connectionDelay < delay {
+			delay, isDeparting = connectionDelay, true
+		}
+	}
+
+	return delay, isDeparting
+}
@@ -0,0 +1,284 @@
+package travelplan
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
+)
+
+/*
+
+Multi-criteria RAPTOR keeps at each stop a bag of labels, none of which is better than another
+both in arrival time and in transfer time, i.e. time spent between trips on walking to other
+stops of the group and waiting. Labels of round k use k trips, so labels at the end stops form
+the Pareto set over arrival time, number of transfers and transfer time.
+
+*/
+
+// Criteria of a journey compared by the multi-criteria search
+type JourneyCriteria struct {
+	ArrivalTime  uint `json:"arrivalTime"`
+	Transfers    uint `json:"transfers"`
+	TransferTime uint `json:"transferTime"`
+}
+
+type paretoLabel struct {
+	arrivalTime, transferTime     uint
+	round                         int
+	trip                          *trip.TramTrip
+	boardStop, alightStop         int
+	boardPosition, alightPosition int
+	parent                        *paretoLabel
+}
+
+func (l *paretoLabel) dominates(other *paretoLabel) bool {
+	return l.arrivalTime <= other.arrivalTime && l.transferTime <= other.transferTime
+}
+
+// Label of a trip boarded in the scanned pattern
+type paretoRouteLabel struct {
+	tripIndex     int
+	boardPosition int
+	transferTime  uint
+	parent        *paretoLabel
+}
+
+type paretoSearch struct {
+	raptorQuery
+	roundLabels   [][]*paretoLabel
+	bestLabels    [][]*paretoLabel
+	targetLabels  []*paretoLabel
+	improvedStops map[int]bool
+}
+
+type paretoJourney struct {
+	tripSequence
+	JourneyCriteria
+}
+
+// Returns journeys of the Pareto set sorted by arrival time and number of transfers
+func (q raptorQuery) getParetoJourneys() []paretoJourney {
+	search := paretoSearch{
+		raptorQuery: q,
+		roundLabels: make([][]*paretoLabel, len(q.timetable.StopIDs)),
+		bestLabels:  make([][]*paretoLabel, len(q.timetable.StopIDs)),
+	}
+
+	markedStops := make(map[int]bool)
+	for _, stop := range q.startStops {
+		search.roundLabels[stop] = []*paretoLabel{{arrivalTime: q.minDepartureTime}}
+		markedStops[stop] = true
+	}
+
+	for round := 1; round <= q.maxTrips && len(markedStops) > 0; round++ {
+		previousLabels := search.roundLabels
+		search.roundLabels = make([][]*paretoLabel, len(q.timetable.StopIDs))
+		search.improvedStops = make(map[int]bool)
+
+		search.scanPatterns(round, slices.Sorted(maps.Keys(markedStops)), previousLabels)
+		markedStops = search.applyTransfers(slices.Sorted(maps.Keys(search.improvedStops)))
+	}
+
+	journeys := make([]paretoJourney, 0, len(search.targetLabels))
+	for _, label := range search.targetLabels {
+		journeys = append(journeys, paretoJourney{
+			tripSequence: getParetoTripSequence(q.timetable, label),
+			JourneyCriteria: JourneyCriteria{
+				ArrivalTime:  label.arrivalTime,
+				Transfers:    uint(label.round - 1),
+				TransferTime: label.transferTime,
+			},
+		})
+	}
+
+	slices.SortStableFunc(journeys, func(j1, j2 paretoJourney) int {
+		return cmp.Or(
+			cmp.Compare(j1.ArrivalTime, j2.ArrivalTime),
+			cmp.Compare(j1.Transfers, j2.Transfers),
+			cmp.Compare(j1.TransferTime, j2.TransferTime),
+		)
+	})
+
+	return journeys
+}
+
+func (s *paretoSearch) scanPatterns(round int, markedStops []int, previousLabels [][]*paretoLabel) {
+	firstPositionByPattern := make(map[int]int)
+	for _, stop := range markedStops {
+		for _, patternStop := range s.timetable.PatternsByStop[stop] {
+			if position, ok := firstPositionByPattern[patternStop.Pattern]; !ok || patternStop.Position < position {
+				firstPositionByPattern[patternStop.Pattern] = patternStop.Position
+			}
+		}
+	}
+
+	for _, patternIndex := range slices.Sorted(maps.Keys(firstPositionByPattern)) {
+		pattern := &s.timetable.Patterns[patternIndex]
+		routeLabels := make([]paretoRouteLabel, 0)
+
+		for position := firstPositionByPattern[patternIndex]; position < len(pattern.Stops); position++ {
+			stop := pattern.Stops[position]
+
+			if s.timetable.IsTransferStop[stop] || s.endStops[stop] {
+				for _, routeLabel := range routeLabels {
+					tramTrip := pattern.Trips[routeLabel.tripIndex]
+					s.addLabel(stop, &paretoLabel{
+						arrivalTime:    tramTrip.Stops[position].Time,
+						transferTime:   routeLabel.transferTime,
+						round:          round,
+						trip:           tramTrip,
+						boardStop:      pattern.Stops[routeLabel.boardPosition],
+						alightStop:     stop,
+						boardPosition:  routeLabel.boardPosition,
+						alightPosition: position,
+						parent:         routeLabel.parent,
+					})
+				}
+			}
+
+			if round > 1 && s.endStops[stop] {
+				continue
+			}
+
+			for _, label := range previousLabels[stop] {
+				if routeLabel, ok := s.getRouteLabel(pattern, label, position); ok {
+					routeLabels = mergeRouteLabel(routeLabels, routeLabel)
+				}
+			}
+		}
+	}
+}
+
+// Returns label of the earliest trip of the pattern which can be boarded after the label
+func (s *paretoSearch) getRouteLabel(pattern *city.RoutePattern, label *paretoLabel, position int) (paretoRouteLabel, bool) {
+	if position == len(pattern.Stops)-1 {
+		return paretoRouteLabel{}, false
+	}
+
+	readyTime, maxDepartureTime := label.arrivalTime, s.spawnTime+MAX_WAITING_TIME
+	if label.round > 0 {
+		readyTime += s.offsetBetweenTransfers
+		maxDepartureTime = label.arrivalTime + MAX_WAITING_TIME
+	}
+	maxDepartureTime = min(maxDepartureTime, s.spawnTime+MAX_TRAVEL_TIME)
+
+	tripIndex, _ := slices.BinarySearchFunc(pattern.Trips, readyTime, func(tramTrip *trip.TramTrip, time uint) int {
+		if tramTrip.Stops[position].Time < time {
+			return -1
+		}
+		return 1
+	})
+
+	if tripIndex == len(pattern.Trips) || pattern.Trips[tripIndex].Stops[position].Time > maxDepartureTime {
+		return paretoRouteLabel{}, false
+	}
+
+	routeLabel := paretoRouteLabel{
+		tripIndex:     tripIndex,
+		boardPosition: position,
+		transferTime:  label.transferTime,
+		parent:        label,
+	}
+
+	// Waiting for the first trip isn't a part of transfer time
+	if label.round > 0 {
+		routeLabel.transferTime += pattern.Trips[tripIndex].Stops[position].Time - label.arrivalTime
+	}
+
+	return routeLabel, true
+}
+
+// Adds the route label unless an earlier trip was boarded with no more transfer time,
+// removing route labels it dominates
+func mergeRouteLabel(routeLabels []paretoRouteLabel, routeLabel paretoRouteLabel) []paretoRouteLabel {
+	for _, other := range routeLabels {
+		if other.tripIndex <= routeLabel.tripIndex && other.transferTime <= routeLabel.transferTime {
+			return routeLabels
+		}
+	}
+
+	routeLabels = slices.DeleteFunc(routeLabels, func(other paretoRouteLabel) bool {
+		return routeLabel.tripIndex <= other.tripIndex && routeLabel.transferTime <= other.transferTime
+	})
+
+	return append(routeLabels, routeLabel)
+}
+
+// Adds the label to the stop unless it's dominated by a label of the stop from this
+// or an earlier round or by a label at the end stops
+func (s *paretoSearch) addLabel(stop int, label *paretoLabel) bool {
+	isDominated := func(other *paretoLabel) bool { return other.dominates(label) }
+	if slices.ContainsFunc(s.bestLabels[stop], isDominated) || slices.ContainsFunc(s.targetLabels, isDominated) {
+		return false
+	}
+
+	isDominatedInRound := func(other *paretoLabel) bool {
+		return other.round == label.round && label.dominates(other)
+	}
+
+	s.roundLabels[stop] = slices.DeleteFunc(s.roundLabels[stop], isDominatedInRound)
+	s.roundLabels[stop] = append(s.roundLabels[stop], label)
+	s.bestLabels[stop] = append(slices.DeleteFunc(s.bestLabels[stop], isDominatedInRound), label)
+
+	if s.endStops[stop] {
+		s.targetLabels = append(slices.DeleteFunc(s.targetLabels, isDominatedInRound), label)
+	} else {
+		s.improvedStops[stop] = true
+	}
+
+	return true
+}
+
+// Applies transfers to other stops of the group from the improved stops,
+// returns stops to scan in the next round
+func (s *paretoSearch) applyTransfers(improvedStops []int) map[int]bool {
+	markedStops := make(map[int]bool)
+
+	for _, stop := range improvedStops {
+		if len(s.roundLabels[stop]) > 0 {
+			markedStops[stop] = true
+		}
+
+		for _, label := range slices.Clone(s.roundLabels[stop]) {
+			if label.alightStop != stop {
+				continue
+			}
+
+			for _, groupStop := range s.timetable.GroupStopsByStop[stop] {
+				if groupStop == stop || s.endStops[groupStop] {
+					continue
+				}
+
+				transferLabel := *label
+				transferLabel.arrivalTime += TRANSFER_TIME
+				transferLabel.transferTime += TRANSFER_TIME
+
+				if s.addLabel(groupStop, &transferLabel) {
+					markedStops[groupStop] = true
+				}
+			}
+		}
+	}
+
+	return markedStops
+}
+
+func getParetoTripSequence(timetable *city.Timetable, label *paretoLabel) tripSequence {
+	trips := make([]*tripRecord, 0, label.round)
+
+	for ; label.round > 0; label = label.parent {
+		trips = append(trips, &tripRecord{
+			tripID:      label.trip.ID,
+			arrivalTime: label.trip.Stops[label.alightPosition].Time,
+			travelTime:  label.trip.GetScheduledTravelTime(label.boardPosition, label.alightPosition),
+			startStopID: timetable.StopIDs[label.boardStop],
+			endStopID:   timetable.StopIDs[label.alightStop],
+		})
+	}
+
+	slices.Reverse(trips)
+	return tripSequence{trips: trips}
+}
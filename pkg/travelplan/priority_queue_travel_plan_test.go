@@ -0,0 +1,241 @@
+package travelplan
+
+import (
+	"cmp"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+/*
+
+Reference travel plan builders replaced by RAPTOR, kept to compare results and speed
+of both engines. Paths are searched with a priority queue of stops reached by trips,
+ordered by arrival time (ASAP and SURE) or by trip count and arrival time (COMFORT),
+until MAX_PATHS paths to the end stops are found.
+
+*/
+
+const MAX_PATHS = 100
+
+type pqTripRecord struct {
+	tripID                  uint
+	arrivalTime, travelTime uint
+	startStopID, endStopID  uint64
+}
+
+type pqTripSequence struct {
+	trips            []*pqTripRecord
+	visitedStopNames structs.Set[string]
+}
+
+func newPQTripSequence(tripCount int) pqTripSequence {
+	return pqTripSequence{
+		trips:            make([]*pqTripRecord, tripCount, tripCount+1),
+		visitedStopNames: structs.NewSet[string](),
+	}
+}
+
+func (t pqTripSequence) extendTripRecords(
+	tripID uint,
+	arrivalTime, travelTime uint,
+	startStopID, endStopID uint64,
+	visitedStops structs.Set[string],
+) pqTripSequence {
+	result := newPQTripSequence(len(t.trips))
+	copy(result.trips, t.trips)
+
+	result.visitedStopNames = t.visitedStopNames.Copy()
+	for stopGroupName := range visitedStops.GetItems() {
+		result.visitedStopNames.Add(stopGroupName)
+	}
+
+	result.trips = append(result.trips, &pqTripRecord{
+		tripID:      tripID,
+		arrivalTime: arrivalTime,
+		travelTime:  travelTime,
+		startStopID: startStopID,
+		endStopID:   endStopID,
+	})
+
+	return result
+}
+
+func (t pqTripSequence) tripCount() uint {
+	return uint(len(t.trips))
+}
+
+func (t pqTripSequence) getArrivalTime() uint {
+	return t.trips[len(t.trips)-1].arrivalTime
+}
+
+func (t pqTripSequence) addToTravelPlan(travelPlan *TravelPlan) {
+	for i, takenTrip := range t.trips {
+		if i > 0 {
+			travelPlan.addTransfer(t.trips[i-1].endStopID, takenTrip.startStopID)
+		}
+
+		travelPlan.addConnection(
+			takenTrip.startStopID,
+			takenTrip.endStopID,
+			takenTrip.tripID,
+			takenTrip.arrivalTime,
+			takenTrip.travelTime,
+		)
+	}
+}
+
+// Stop reached by a trip with the trips taken to reach it
+type pqValue struct {
+	stopID      uint64
+	arrivalTime uint
+	takenTrips  pqTripSequence
+}
+
+type pqPriority struct {
+	tripCount, timeSinceSpawn uint
+}
+
+type pqTravelPlanBuilder struct {
+	currentCity            *city.City
+	endStopIDs             structs.Set[uint64]
+	spawnTime              uint
+	offsetBetweenTransfers uint
+	preferFewerTrips       bool
+	minTripCount           uint
+	foundPaths             []pqTripSequence
+	tripsPriorityQueue     structs.PriorityQueue[pqValue, pqPriority]
+}
+
+// Returns paths found by the reference builder, in the order they were found
+func getPriorityQueuePaths(
+	currentCity *city.City,
+	strategy TravelPlanStrategy,
+	startStopIDs []uint64,
+	endStopIDs structs.Set[uint64],
+	spawnTime uint,
+) []pqTripSequence {
+	builder := pqTravelPlanBuilder{
+		currentCity:      currentCity,
+		endStopIDs:       endStopIDs,
+		spawnTime:        spawnTime,
+		preferFewerTrips: strategy == COMFORT,
+		minTripCount:     MAX_TRIPS + 1,
+		foundPaths:       make([]pqTripSequence, 0),
+	}
+
+	if strategy == SURE {
+		builder.offsetBetweenTransfers = SURE_TRANSFER_OFFSET
+	}
+
+	builder.tripsPriorityQueue = structs.NewPriorityQueue[pqValue](func(left, right pqPriority) int {
+		if builder.preferFewerTrips {
+			return cmp.Or(cmp.Compare(left.tripCount, right.tripCount), cmp.Compare(left.timeSinceSpawn, right.timeSinceSpawn))
+		}
+		return cmp.Compare(left.timeSinceSpawn, right.timeSinceSpawn)
+	})
+
+	for _, startStopID := range startStopIDs {
+		builder.addTripsFromStop(startStopID, spawnTime, spawnTime+MAX_WAITING_TIME, newPQTripSequence(0))
+	}
+
+	for builder.tripsPriorityQueue.Len() > 0 && len(builder.foundPaths) < MAX_PATHS {
+		value := builder.tripsPriorityQueue.Pop()
+
+		// Paths of COMFORT strategy are ordered by trip count, so no path
+		// with fewer trips than the ones found can be found later
+		if builder.preferFewerTrips && value.takenTrips.tripCount() >= builder.minTripCount {
+			break
+		}
+
+		for _, transferStopID := range currentCity.GetStopIDsInGroup(value.stopID) {
+			startTime, endTime := value.arrivalTime+builder.offsetBetweenTransfers, value.arrivalTime+MAX_WAITING_TIME
+			if value.stopID != transferStopID {
+				startTime += TRANSFER_TIME
+			}
+
+			builder.addTripsFromStop(transferStopID, startTime, endTime, value.takenTrips)
+		}
+	}
+
+	return builder.foundPaths
+}
+
+// Returns travel plan of the reference builder with all found paths
+func getPriorityQueueTravelPlan(
+	currentCity *city.City,
+	strategy TravelPlanStrategy,
+	startStopIDs []uint64,
+	endStopIDs structs.Set[uint64],
+	spawnTime uint,
+) (TravelPlan, bool) {
+	foundPaths := getPriorityQueuePaths(currentCity, strategy, startStopIDs, endStopIDs, spawnTime)
+	if len(foundPaths) == 0 {
+		return TravelPlan{}, false
+	}
+
+	travelPlan := NewTravelPlan(foundPaths[0].trips[0].startStopID, endStopIDs, spawnTime)
+	for _, path := range foundPaths {
+		path.addToTravelPlan(&travelPlan)
+	}
+
+	return travelPlan, true
+}
+
+func (b *pqTravelPlanBuilder) addTripsFromStop(stopID uint64, startTime, endTime uint, takenTrips pqTripSequence) {
+	arrivals := b.currentCity.GetPlannedArrivalsInTimeSpan(stopID, startTime, min(endTime, b.spawnTime+MAX_TRAVEL_TIME))
+
+	for _, arrival := range arrivals {
+		tripCount := takenTrips.tripCount()
+		if tripCount > 0 && arrival.TripID == takenTrips.trips[tripCount-1].tripID {
+			continue
+		}
+
+		b.addStopsAlongTrip(arrival, takenTrips)
+	}
+}
+
+func (b *pqTravelPlanBuilder) addStopsAlongTrip(arrival city.PlannedArrival, takenTrips pqTripSequence) {
+	if takenTrips.tripCount() >= MAX_TRIPS {
+		return
+	}
+
+	tramTrip := b.currentCity.GetTripByID(arrival.TripID)
+
+	visitedStops := structs.NewSet[string]()
+	visitedStops.Add(b.currentCity.GetStopByID(tramTrip.Stops[arrival.StopIndex].ID).GetGroupName())
+
+	for _, stop := range tramTrip.Stops[arrival.StopIndex+1:] {
+		stopGroupName := b.currentCity.GetStopByID(stop.ID).GetGroupName()
+		visitedStops.Add(stopGroupName)
+
+		if takenTrips.visitedStopNames.Includes(stopGroupName) {
+			continue
+		}
+
+		// Transfer only on transfer stops, but allow ending trips at end stops
+		if !b.currentCity.IsTransferStop(stop.ID) && !b.endStopIDs.Includes(stop.ID) {
+			continue
+		}
+
+		takenTripsAfterStop := takenTrips.extendTripRecords(
+			tramTrip.ID,
+			stop.Time,
+			stop.Time-arrival.Time,
+			tramTrip.Stops[arrival.StopIndex].ID,
+			stop.ID,
+			visitedStops,
+		)
+
+		if b.endStopIDs.Includes(stop.ID) {
+			b.minTripCount = min(b.minTripCount, takenTripsAfterStop.tripCount())
+			b.foundPaths = append(b.foundPaths, takenTripsAfterStop)
+			break
+		}
+
+		b.tripsPriorityQueue.Push(
+			pqValue{stopID: stop.ID, arrivalTime: stop.Time, takenTrips: takenTripsAfterStop},
+			pqPriority{tripCount: takenTripsAfterStop.tripCount(), timeSinceSpawn: stop.Time - b.spawnTime},
+		)
+	}
+}
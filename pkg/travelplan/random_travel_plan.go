@@ -1,7 +1,7 @@
 package travelplan
 
 import (
-	"math/rand"
+	"math/rand/v2"
 
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
@@ -24,15 +24,22 @@ const (
 type randomTravelPlan struct {
 	TravelPlan
 	currentCity *city.City
+	random      *rand.Rand
 }
 
-func GetRandomTravelPlan(currentCity *city.City, startStopID uint64, spawnTime uint) (TravelPlan, bool) {
+func GetRandomTravelPlan(
+	currentCity *city.City,
+	startStopID uint64,
+	spawnTime uint,
+	random *rand.Rand,
+) (TravelPlan, bool) {
 	rtp := randomTravelPlan{
 		TravelPlan:  NewTravelPlan(startStopID, structs.NewSet[uint64](), spawnTime),
 		currentCity: currentCity,
+		random:      random,
 	}
 
-	isPassengerChangingStops := rand.Float32() < TRANSFER_PROBABILITY
+	isPassengerChangingStops := random.Float32() < TRANSFER_PROBABILITY
 
 	// direct trip
 	if !isPassengerChangingStops {
@@ -49,17 +56,8 @@ func GetRandomTravelPlan(currentCity *city.City, startStopID uint64, spawnTime u
 	}
 
 	// select random stop to transfer to
-	stops := currentCity.GetStopsInGroup(intermediateStopID)
-	chosenStopID, i := rand.Intn(len(stops)), 0
-
-	var transferStopID uint64
-	for handleStopID := range stops {
-		if i == chosenStopID {
-			transferStopID = handleStopID
-			break
-		}
-		i++
-	}
+	stopIDs := currentCity.GetStopIDsInGroup(intermediateStopID)
+	transferStopID := stopIDs[random.IntN(len(stopIDs))]
 
 	rtp.TravelPlan.addTransfer(intermediateStopID, transferStopID)
 	endStopID, _ := rtp.findConnectionToStop(transferStopID, time+TRANSFER_TIME, false)
@@ -127,12 +125,12 @@ func (rtp *randomTravelPlan) findTransferStop(trip *trip.TramTrip, arrival *city
 		return 0, 0, false
 	}
 
-	destination := transferStops[rand.Intn(len(transferStops))]
+	destination := transferStops[rtp.random.IntN(len(transferStops))]
 	return destination.stopID, destination.arrivalTime - arrival.Time, true
 }
 
 func (rtp *randomTravelPlan) selectRandomStop(trip *trip.TramTrip, arrival *city.PlannedArrival, stopsLeft int) (uint64, uint) {
-	stopsToTravel := rand.Intn(stopsLeft) + 1 // Travel for at least 1 stop
+	stopsToTravel := rtp.random.IntN(stopsLeft) + 1 // Travel for at least 1 stop
 	toStopIndex := arrival.StopIndex + stopsToTravel
 	toStopID := trip.Stops[toStopIndex].ID
 	travelTime := trip.Stops[toStopIndex].Time - arrival.Time
@@ -160,7 +158,7 @@ func (rtp *randomTravelPlan) getRandomArrivalFromStop(stopID uint64, time uint)
 
 	// try up to 10 times to find a valid arrival with stops left
 	for range 10 {
-		arrival = &filteredArrivals[rand.Intn(len(filteredArrivals))]
+		arrival = &filteredArrivals[rtp.random.IntN(len(filteredArrivals))]
 		stopsTotal := len(trips[arrival.TripID].Stops)
 		stopsLeft = stopsTotal - arrival.StopIndex - 1
 		if stopsLeft > 0 {
@@ -0,0 +1,364 @@
+package travelplan
+
+import (
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city/trip"
+)
+
+/*
+
+RAPTOR (Round-bAsed Public Transit Optimized Router) computes in round k the earliest
+arrival at each stop using at most k trips. Each round scans route patterns through the stops
+improved in the previous round, boarding the earliest trip catchable at each stop,
+and then applies transfers between stops of the same group.
+
+*/
+
+// Number of journeys departing later than the best one, added to the travel plan as alternatives.
+// Passengers board only trips of journeys in their travel plan, so the alternatives are
+// their next chances to travel if they miss trips of the best journey. They start from
+// the same stop as the best journey, as passengers wait for the first trip at one stop.
+const MAX_ALTERNATIVE_JOURNEYS = 2
+
+const unreachedTime = math.MaxUint
+
+// Arrival at a stop in a round, by the trip from the boarding stop
+// and optionally a transfer from the alighting stop
+type raptorLabel struct {
+	arrivalTime                   uint
+	round                         int
+	trip                          *trip.TramTrip
+	boardStop, alightStop         int
+	boardPosition, alightPosition int
+}
+
+type raptorQuery struct {
+	timetable              *city.Timetable
+	startStops             []int
+	endStops               []bool
+	spawnTime              uint
+	minDepartureTime       uint
+	offsetBetweenTransfers uint
+	maxTrips               int
+	preferFewerTrips       bool
+	liveEstimates          LiveEstimates
+}
+
+type raptorSearch struct {
+	raptorQuery
+	labels        [][]raptorLabel
+	bestArrivals  []uint
+	targetArrival uint
+}
+
+func newRaptorQuery(
+	currentCity *city.City,
+	startStopIDs []uint64,
+	endStopIDs []uint64,
+	spawnTime, offsetBetweenTransfers uint,
+	preferFewerTrips bool,
+) raptorQuery {
+	timetable := currentCity.GetTimetable()
+
+	query := raptorQuery{
+		timetable:              timetable,
+		startStops:             make([]int, 0, len(startStopIDs)),
+		endStops:               make([]bool, len(timetable.StopIDs)),
+		spawnTime:              spawnTime,
+		minDepartureTime:       spawnTime,
+		offsetBetweenTransfers: offsetBetweenTransfers,
+		maxTrips:               MAX_TRIPS,
+		preferFewerTrips:       preferFewerTrips,
+	}
+
+	for _, stopID := range startStopIDs {
+		if stop, ok := timetable.StopIndexByID[stopID]; ok {
+			query.startStops = append(query.startStops, stop)
+		}
+	}
+
+	for _, stopID := range endStopIDs {
+		if stop, ok := timetable.StopIndexByID[stopID]; ok {
+			query.endStops[stop] = true
+		}
+	}
+
+	return query
+}
+
+// Returns the best journey of the query or false if the end stops can't be reached
+func (q raptorQuery) getJourney() (tripSequence, bool) {
+	search := raptorSearch{
+		raptorQuery:   q,
+		labels:        make([][]raptorLabel, q.maxTrips+1),
+		bestArrivals:  make([]uint, len(q.timetable.StopIDs)),
+		targetArrival: unreachedTime,
+	}
+
+	for round := range search.labels {
+		search.labels[round] = make([]raptorLabel, len(q.timetable.StopIDs))
+	}
+
+	for stop := range search.bestArrivals {
+		search.labels[0][stop].arrivalTime = unreachedTime
+		search.bestArrivals[stop] = unreachedTime
+	}
+
+	for _, stop := range q.startStops {
+		search.labels[0][stop].arrivalTime = q.minDepartureTime
+	}
+
+	return search.run()
+}
+
+func (s *raptorSearch) run() (tripSequence, bool) {
+	markedStops := slices.Clone(s.startStops)
+	bestRound, bestEndStop := 0, -1
+
+	for round := 1; round <= s.maxTrips && len(markedStops) > 0; round++ {
+		copy(s.labels[round], s.labels[round-1])
+
+		improvedStops := s.scanPatterns(round, markedStops)
+		markedStops = s.applyTransfers(round, improvedStops)
+
+		for _, stop := range improvedStops {
+			label := &s.labels[round][stop]
+			if s.endStops[stop] && (bestEndStop == -1 || label.arrivalTime < s.labels[bestRound][bestEndStop].arrivalTime) {
+				bestRound, bestEndStop = round, stop
+			}
+		}
+
+		if bestEndStop != -1 && s.preferFewerTrips {
+			break
+		}
+	}
+
+	if bestEndStop == -1 {
+		return tripSequence{}, false
+	}
+
+	return s.getTripSequence(bestRound, bestEndStop), true
+}
+
+// Scans patterns through the marked stops from the earliest marked position,
+// returns stops with arrival improved by the scanned trips
+func (s *raptorSearch) scanPatterns(round int, markedStops []int) []int {
+	firstPositionByPattern := make(map[int]int)
+	for _, stop := range markedStops {
+		for _, patternStop := range s.timetable.PatternsByStop[stop] {
+			if position, ok := firstPositionByPattern[patternStop.Pattern]; !ok || patternStop.Position < position {
+				firstPositionByPattern[patternStop.Pattern] = patternStop.Position
+			}
+		}
+	}
+
+	improvedStops := make([]int, 0)
+
+	for _, patternIndex := range slices.Sorted(maps.Keys(firstPositionByPattern)) {
+		pattern := &s.timetable.Patterns[patternIndex]
+		tripIndex, boardPosition := -1, 0
+
+		for position := firstPositionByPattern[patternIndex]; position < len(pattern.Stops); position++ {
+			stop := pattern.Stops[position]
+
+			if tripIndex != -1 && s.alight(round, pattern.Trips[tripIndex], stop, pattern.Stops[boardPosition], boardPosition, position) {
+				improvedStops = append(improvedStops, stop)
+			}
+
+			if earlierTripIndex := s.getEarliestTripIndex(round, pattern, stop, position); earlierTripIndex != -1 &&
+				(tripIndex == -1 || s.isEarlierTrip(pattern, earlierTripIndex, tripIndex, position)) {
+				tripIndex, boardPosition = earlierTripIndex, position
+			}
+		}
+	}
+
+	return improvedStops
+}
+
+// Returns index of the earliest trip of the pattern which can be boarded at the stop
+// after the arrival in the previous round, or -1 if there is none
+func (s *raptorSearch) getEarliestTripIndex(round int, pattern *city.RoutePattern, stop, position int) int {
+	previous := &s.labels[round-1][stop]
+	if previous.arrivalTime == unreachedTime || (round > 1 && s.endStops[stop]) {
+		return -1
+	}
+
+	readyTime, maxDepartureTime := previous.arrivalTime, s.spawnTime+MAX_WAITING_TIME
+	if previous.round > 0 {
+		readyTime += s.offsetBetweenTransfers
+		maxDepartureTime = previous.arrivalTime + MAX_WAITING_TIME
+	}
+	maxDepartureTime = min(maxDepartureTime, s.spawnTime+MAX_TRAVEL_TIME)
+
+	// Don't board trips at their last stop
+	if position == len(pattern.Stops)-1 {
+		return -1
+	}
+
+	if s.liveEstimates != nil {
+		return s.getEarliestLiveTripIndex(pattern, position, readyTime, maxDepartureTime)
+	}
+
+	tripIndex := getFirstTripIndex(pattern, position, readyTime)
+	if tripIndex == len(pattern.Trips) || pattern.Trips[tripIndex].Stops[position].Time > maxDepartureTime {
+		return -1
+	}
+
+	return tripIndex
+}
+
+// Returns index of the trip estimated to depart the earliest between the given times,
+// or -1 if there is none. Trips delayed by more than the maximum waiting time aren't considered.
+func (q raptorQuery) getEarliestLiveTripIndex(pattern *city.RoutePattern, position int, readyTime, maxDepartureTime uint) int {
+	tripIndex, departureTime := -1, uint(0)
+
+	for i := getFirstTripIndex(pattern, position, readyTime-min(readyTime, MAX_WAITING_TIME)); i < len(pattern.Trips) &&
+		pattern.Trips[i].Stops[position].Time <= maxDepartureTime; i++ {
+		time, ok := q.getTime(pattern.Trips[i], position)
+		if ok && time >= readyTime && time <= maxDepartureTime && (tripIndex == -1 || time < departureTime) {
+			tripIndex, departureTime = i, time
+		}
+	}
+
+	return tripIndex
+}
+
+// Returns index of the first trip of the pattern at the position not earlier than the time
+func getFirstTripIndex(pattern *city.RoutePattern, position int, time uint) int {
+	tripIndex, _ := slices.BinarySearchFunc(pattern.Trips, time, func(tramTrip *trip.TramTrip, time uint) int {
+		if tramTrip.Stops[position].Time < time {
+			return -1
+		}
+		return 1
+	})
+
+	return tripIndex
+}
+
+// Returns time of the trip at the position, estimated if live estimates are given,
+// or false if the trip won't stop there
+func (q raptorQuery) getTime(tramTrip *trip.TramTrip, position int) (uint, bool) {
+	if q.liveEstimates == nil {
+		return tramTrip.Stops[position].Time, true
+	}
+
+	return q.liveEstimates.GetEstimatedTime(tramTrip.ID, position)
+}
+
+// Checks if the first trip departs from the position before the second one. Trips of a pattern
+// keep their order, unless their estimated times are used.
+func (q raptorQuery) isEarlierTrip(pattern *city.RoutePattern, tripIndex, otherTripIndex, position int) bool {
+	if q.liveEstimates == nil {
+		return tripIndex < otherTripIndex
+	}
+
+	time, _ := q.getTime(pattern.Trips[tripIndex], position)
+	otherTime, ok := q.getTime(pattern.Trips[otherTripIndex], position)
+	return !ok || time < otherTime
+}
+
+// Updates arrival at the stop by the trip, if it's improved. Passengers get off
+// only at transfer stops or at the end stops.
+func (s *raptorSearch) alight(round int, tramTrip *trip.TramTrip, stop, boardStop, boardPosition, position int) bool {
+	if !s.timetable.IsTransferStop[stop] && !s.endStops[stop] {
+		return false
+	}
+
+	arrivalTime, ok := s.getTime(tramTrip, position)
+	if !ok || arrivalTime >= min(s.bestArrivals[stop], s.targetArrival) {
+		return false
+	}
+
+	s.labels[round][stop] = raptorLabel{
+		arrivalTime:    arrivalTime,
+		round:          round,
+		trip:           tramTrip,
+		boardStop:      boardStop,
+		alightStop:     stop,
+		boardPosition:  boardPosition,
+		alightPosition: position,
+	}
+	s.bestArrivals[stop] = arrivalTime
+
+	if s.endStops[stop] {
+		s.targetArrival = arrivalTime
+	}
+
+	return true
+}
+
+// Applies transfers to other stops of the group from the improved stops,
+// returns stops to scan in the next round
+func (s *raptorSearch) applyTransfers(round int, improvedStops []int) []int {
+	markedStops := make([]int, 0, len(improvedStops))
+
+	for _, stop := range improvedStops {
+		if s.endStops[stop] {
+			continue
+		}
+
+		label := s.labels[round][stop]
+		markedStops = append(markedStops, stop)
+
+		for _, groupStop := range s.timetable.GroupStopsByStop[stop] {
+			arrivalTime := label.arrivalTime + TRANSFER_TIME
+			if groupStop == stop || s.endStops[groupStop] || arrivalTime >= min(s.bestArrivals[groupStop], s.targetArrival) {
+				continue
+			}
+
+			s.labels[round][groupStop] = label
+			s.labels[round][groupStop].arrivalTime = arrivalTime
+			s.bestArrivals[groupStop] = arrivalTime
+			markedStops = append(markedStops, groupStop)
+		}
+	}
+
+	return markedStops
+}
+
+func (s *raptorSearch) getTripSequence(round, endStop int) tripSequence {
+	trips := make([]*tripRecord, 0, round)
+	stopIDs := s.timetable.StopIDs
+
+	for label := s.labels[round][endStop]; label.round > 0; label = s.labels[label.round-1][label.boardStop] {
+		trips = append(trips, &tripRecord{
+			tripID:      label.trip.ID,
+			arrivalTime: label.trip.Stops[label.alightPosition].Time,
+			travelTime:  label.trip.GetScheduledTravelTime(label.boardPosition, label.alightPosition),
+			startStopID: stopIDs[label.boardStop],
+			endStopID:   stopIDs[label.alightStop],
+		})
+	}
+
+	slices.Reverse(trips)
+	return tripSequence{trips: trips}
+}
+
+// Returns the best journey and journeys departing later from the same start stop
+// with at most as many trips, with the best journey first
+func (q raptorQuery) getJourneys() ([]tripSequence, bool) {
+	journey, ok := q.getJourney()
+	if !ok {
+		return nil, false
+	}
+
+	journeys := []tripSequence{journey}
+
+	alternativeQuery := q
+	alternativeQuery.startStops = []int{q.timetable.StopIndexByID[journey.getStartStopID()]}
+	alternativeQuery.maxTrips = int(journey.tripCount())
+
+	for range MAX_ALTERNATIVE_JOURNEYS {
+		alternativeQuery.minDepartureTime = journey.getDepartureTime() + 1
+		if journey, ok = alternativeQuery.getJourney(); !ok {
+			break
+		}
+		journeys = append(journeys, journey)
+	}
+
+	return journeys, true
+}
@@ -0,0 +1,108 @@
+package travelplan
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
+)
+
+type TravelConnectionSnapshot struct {
+	TripID      uint   `json:"tripID"`
+	To          uint64 `json:"to"`
+	ArrivalTime uint   `json:"arrivalTime"`
+	TravelTime  uint   `json:"travelTime"`
+}
+
+type TravelStopSnapshot struct {
+	ID             uint64                     `json:"id"`
+	TransferToStop uint64                     `json:"transferToStop"`
+	Connections    []TravelConnectionSnapshot `json:"connections"`
+}
+
+type TravelPlanSnapshot struct {
+	Stops       []TravelStopSnapshot       `json:"stops"`
+	Connections []TravelConnectionSnapshot `json:"connections"`
+	StartStopID uint64                     `json:"startStopID"`
+	EndStopIDs  []uint64                   `json:"endStopIDs"`
+	SpawnTime   uint                       `json:"spawnTime"`
+	// Optional, missing in snapshots of simulations without journeys of travel plans
+	Journey []JourneyLeg `json:"journey,omitempty"`
+}
+
+func newTravelConnectionSnapshots(connections map[uint]*travelConnection) []TravelConnectionSnapshot {
+	result := make([]TravelConnectionSnapshot, 0, len(connections))
+
+	for _, conn := range connections {
+		result = append(result, TravelConnectionSnapshot{
+			TripID:      conn.id,
+			To:          conn.to,
+			ArrivalTime: conn.arrivalTime,
+			TravelTime:  conn.travelTime,
+		})
+	}
+
+	slices.SortFunc(result, func(c1, c2 TravelConnectionSnapshot) int {
+		return cmp.Compare(c1.TripID, c2.TripID)
+	})
+
+	return result
+}
+
+func restoreTravelConnections(snapshots []TravelConnectionSnapshot) map[uint]*travelConnection {
+	result := make(map[uint]*travelConnection, len(snapshots))
+
+	for _, conn := range snapshots {
+		result[conn.TripID] = &travelConnection{
+			id:          conn.TripID,
+			to:          conn.To,
+			arrivalTime: conn.ArrivalTime,
+			travelTime:  conn.TravelTime,
+		}
+	}
+
+	return result
+}
+
+func (tp TravelPlan) GetSnapshot() TravelPlanSnapshot {
+	stops := make([]TravelStopSnapshot, 0, len(tp.stops))
+	for _, stopID := range slices.Sorted(maps.Keys(tp.stops)) {
+		stop := tp.stops[stopID]
+		stops = append(stops, TravelStopSnapshot{
+			ID:             stop.id,
+			TransferToStop: stop.transferToStop,
+			Connections:    newTravelConnectionSnapshots(stop.connections),
+		})
+	}
+
+	return TravelPlanSnapshot{
+		Stops:       stops,
+		Connections: newTravelConnectionSnapshots(tp.connections),
+		StartStopID: tp.startStopID,
+		EndStopIDs:  slices.Sorted(tp.endStopIDs.GetItems()),
+		SpawnTime:   tp.spawnTime,
+		Journey:     tp.journey.getLegs(),
+	}
+}
+
+func TravelPlanFromSnapshot(snapshot TravelPlanSnapshot) TravelPlan {
+	endStopIDs := structs.NewSet[uint64]()
+	for _, stopID := range snapshot.EndStopIDs {
+		endStopIDs.Add(stopID)
+	}
+
+	travelPlan := NewTravelPlan(snapshot.StartStopID, endStopIDs, snapshot.SpawnTime)
+	travelPlan.connections = restoreTravelConnections(snapshot.Connections)
+	travelPlan.journey = tripSequenceFromLegs(snapshot.Journey)
+
+	for _, stop := range snapshot.Stops {
+		travelPlan.stops[stop.ID] = &travelStop{
+			id:             stop.ID,
+			transferToStop: stop.TransferToStop,
+			connections:    restoreTravelConnections(stop.Connections),
+		}
+	}
+
+	return travelPlan
+}
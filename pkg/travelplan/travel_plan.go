@@ -2,7 +2,10 @@ package travelplan
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/TNSEngineerEdition/WailsClient/pkg/city"
 	"github.com/TNSEngineerEdition/WailsClient/pkg/structs"
 )
 
@@ -10,7 +13,6 @@ const (
 	MAX_WAITING_TIME = 30 * 60     // 30 minutes
 	MAX_TRAVEL_TIME  = 2 * 60 * 60 // 2 hours
 	MAX_TRIPS        = 4
-	MAX_PATHS        = 100
 	TRANSFER_TIME    = 2 * 60 // 2 min
 )
 
@@ -31,6 +33,7 @@ type TravelPlan struct {
 	startStopID uint64
 	endStopIDs  structs.Set[uint64]
 	spawnTime   uint
+	journey     tripSequence
 }
 
 func NewTravelPlan(startStopID uint64, endStopIDs structs.Set[uint64], spawnTime uint) TravelPlan {
@@ -47,6 +50,26 @@ func (tp TravelPlan) GetStartStopID() uint64 {
 	return tp.startStopID
 }
 
+func (tp TravelPlan) GetEndStopIDs() structs.Set[uint64] {
+	return tp.endStopIDs
+}
+
+// Returns scheduled arrival at the end stops by the best journey of the travel plan,
+// 0 if it's unknown, e.g. for random travel plans
+func (tp TravelPlan) GetArrivalTime() uint {
+	if tp.journey.tripCount() == 0 {
+		return 0
+	}
+
+	return tp.journey.trips[len(tp.journey.trips)-1].arrivalTime
+}
+
+// Returns trips of the best journey of the travel plan with their scheduled times,
+// empty for random travel plans
+func (tp TravelPlan) GetJourney() []JourneyLeg {
+	return tp.journey.getLegs()
+}
+
 func (tp TravelPlan) GetConnectionTransferDestination(stopID uint64) uint64 {
 	if stop, ok := tp.stops[stopID]; ok {
 		return stop.transferToStop
@@ -75,6 +98,11 @@ func (tp TravelPlan) ContainsConnection(stopID uint64, tramID uint) bool {
 	return false
 }
 
+func (tp TravelPlan) ContainsStop(stopID uint64) bool {
+	_, ok := tp.stops[stopID]
+	return ok
+}
+
 func (tp TravelPlan) IsEndStopReached(stopID uint64) bool {
 	return tp.endStopIDs.Includes(stopID)
 }
@@ -106,6 +134,103 @@ func (tp *TravelPlan) addConnection(from, to uint64, tripID, arrivalTime, travel
 	tp.connections[tripID] = &conn
 }
 
+func (tp *TravelPlan) removeConnection(from uint64, tripID uint) {
+	removed := tp.stops[from].connections[tripID]
+	delete(tp.stops[from].connections, tripID)
+
+	if tp.connections[tripID] != removed {
+		return
+	}
+
+	delete(tp.connections, tripID)
+
+	// The same trip may still be taken from another stop
+	for _, stopID := range slices.Sorted(maps.Keys(tp.stops)) {
+		if conn, ok := tp.stops[stopID].connections[tripID]; ok {
+			tp.connections[tripID] = conn
+			return
+		}
+	}
+}
+
+// Removes connection of the trip from the stop, after the passenger couldn't board it.
+// If there are no other connections from the stop, the next trip going from the stop
+// to the same destination is added instead, if the rest of the travel plan can still
+// be followed after it. Returns false if there are no more connections from the stop.
+func (tp *TravelPlan) ReplaceMissedConnection(c *city.City, stopID uint64, tripID, time uint) bool {
+	stop, ok := tp.stops[stopID]
+	if !ok {
+		return false
+	}
+
+	missed, ok := stop.connections[tripID]
+	if !ok {
+		return len(stop.connections) > 0
+	}
+
+	tp.removeConnection(stopID, tripID)
+	if len(stop.connections) > 0 {
+		return true
+	}
+
+	for _, arrival := range c.GetPlannedArrivalsInTimeSpan(stopID, time, time+MAX_WAITING_TIME) {
+		if arrival.TripID == tripID {
+			continue
+		}
+
+		tramTrip := c.GetTripByID(arrival.TripID)
+		for _, tripStop := range tramTrip.Stops[arrival.StopIndex+1:] {
+			if tripStop.ID != missed.to {
+				continue
+			}
+
+			if tp.isEndStopReachable(missed.to, tripStop.Time) {
+				tp.addConnection(stopID, missed.to, tramTrip.ID, tripStop.Time, tripStop.Time-arrival.Time)
+				return true
+			}
+			break
+		}
+	}
+
+	return false
+}
+
+// Checks if the end stops can be reached by following transfers and the earliest
+// arriving connections of the travel plan, when arriving at the stop at the given time
+func (tp *TravelPlan) isEndStopReachable(stopID uint64, arrivalTime uint) bool {
+	// each stop is visited at most once, as arrival times increase
+	for range len(tp.stops) {
+		if tp.endStopIDs.Includes(stopID) {
+			return true
+		}
+
+		stop, ok := tp.stops[stopID]
+		if !ok || stop.transferToStop == 0 {
+			return false
+		}
+
+		readyTime := arrivalTime
+		if stop.transferToStop != stopID {
+			readyTime += TRANSFER_TIME
+		}
+
+		var next *travelConnection
+		for _, conn := range tp.stops[stop.transferToStop].connections {
+			if conn.arrivalTime-conn.travelTime >= readyTime && (next == nil || conn.arrivalTime < next.arrivalTime) {
+				next = conn
+			}
+		}
+
+		if next == nil {
+			return false
+		}
+
+		stopID, arrivalTime = next.to, next.arrivalTime
+	}
+
+	return false
+}
+
 func (tp *TravelPlan) addTransfer(from, to uint64) {
 	tp.addStop(from)
 	tp.addStop(to)
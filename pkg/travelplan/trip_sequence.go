@@ -1,48 +1,22 @@
 package travelplan
 
-import "github.com/TNSEngineerEdition/WailsClient/pkg/structs"
-
 type tripRecord struct {
 	tripID                  uint
 	arrivalTime, travelTime uint
 	startStopID, endStopID  uint64
 }
 
-type tripSequence struct {
-	trips            []*tripRecord
-	visitedStopNames structs.Set[string]
-}
-
-func newTripSequence(tripCount int) tripSequence {
-	return tripSequence{
-		trips:            make([]*tripRecord, tripCount, tripCount+1),
-		visitedStopNames: structs.NewSet[string](),
-	}
+// Trip taken from the start stop to the end stop of a journey
+type JourneyLeg struct {
+	TripID        uint   `json:"tripID"`
+	StartStopID   uint64 `json:"startStopID"`
+	EndStopID     uint64 `json:"endStopID"`
+	DepartureTime uint   `json:"departureTime"`
+	ArrivalTime   uint   `json:"arrivalTime"`
 }
 
-func (t tripSequence) extendTripRecords(
-	tripID uint,
-	arrivalTime, travelTime uint,
-	startStopID, endStopID uint64,
-	visitedStops structs.Set[string],
-) tripSequence {
-	result := newTripSequence(len(t.trips))
-	copy(result.trips, t.trips)
-
-	result.visitedStopNames = t.visitedStopNames.Copy()
-	for stopGroupName := range visitedStops.GetItems() {
-		result.visitedStopNames.Add(stopGroupName)
-	}
-
-	result.trips = append(result.trips, &tripRecord{
-		tripID:      tripID,
-		arrivalTime: arrivalTime,
-		travelTime:  travelTime,
-		startStopID: startStopID,
-		endStopID:   endStopID,
-	})
-
-	return result
+type tripSequence struct {
+	trips []*tripRecord
 }
 
 func (t tripSequence) tripCount() uint {
@@ -53,6 +27,10 @@ func (t tripSequence) getStartStopID() uint64 {
 	return t.trips[0].startStopID
 }
 
+func (t tripSequence) getDepartureTime() uint {
+	return t.trips[0].arrivalTime - t.trips[0].travelTime
+}
+
 func (t tripSequence) addToTravelPlan(travelPlan *TravelPlan) {
 	for i, takenTrip := range t.trips {
 		if i > 0 {
@@ -67,4 +45,34 @@ func (t tripSequence) addToTravelPlan(travelPlan *TravelPlan) {
 			takenTrip.travelTime,
 		)
 	}
+
+	travelPlan.journey = t
+}
+
+func (t tripSequence) getLegs() []JourneyLeg {
+	legs := make([]JourneyLeg, 0, len(t.trips))
+	for _, takenTrip := range t.trips {
+		legs = append(legs, JourneyLeg{
+			TripID:        takenTrip.tripID,
+			StartStopID:   takenTrip.startStopID,
+			EndStopID:     takenTrip.endStopID,
+			DepartureTime: takenTrip.arrivalTime - takenTrip.travelTime,
+			ArrivalTime:   takenTrip.arrivalTime,
+		})
+	}
+	return legs
+}
+
+func tripSequenceFromLegs(legs []JourneyLeg) tripSequence {
+	trips := make([]*tripRecord, 0, len(legs))
+	for _, leg := range legs {
+		trips = append(trips, &tripRecord{
+			tripID:      leg.TripID,
+			arrivalTime: leg.ArrivalTime,
+			travelTime:  leg.ArrivalTime - leg.DepartureTime,
+			startStopID: leg.StartStopID,
+			endStopID:   leg.EndStopID,
+		})
+	}
+	return tripSequence{trips: trips}
 }